@@ -0,0 +1,174 @@
+package cast
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	awsAlgorithm       = "AWS4-HMAC-SHA256"
+	awsTimeFormat      = "20060102T150405Z"
+	awsDateFormat      = "20060102"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDate            = "X-Amz-Date"
+	amzContentSha256   = "X-Amz-Content-Sha256"
+	amzSecurityToken   = "X-Amz-Security-Token"
+)
+
+// headers left out of the AWS signature, as they may be changed on the way.
+var awsIgnoredHeaders = map[string]bool{
+	"Authorization":   true,
+	"User-Agent":      true,
+	"X-Amzn-Trace-Id": true,
+	"Expect":          true,
+	"Connection":      true,
+}
+
+// AWSSigner signs requests with AWS Signature Version 4.
+type AWSSigner struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is sent as X-Amz-Security-Token if not empty.
+	SessionToken string
+	Region       string
+	Service      string
+	// Now returns the signing time, time.Now if nil.
+	Now func() time.Time
+}
+
+// Sign implements Signer. A streamed body is signed as UNSIGNED-PAYLOAD.
+func (s *AWSSigner) Sign(request *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	t := now().UTC()
+	date := t.Format(awsDateFormat)
+
+	request.Header.Del(authorization)
+	request.Header.Set(amzDate, t.Format(awsTimeFormat))
+	if len(s.SessionToken) > 0 {
+		request.Header.Set(amzSecurityToken, s.SessionToken)
+	}
+	payloadHash := awsUnsignedPayload
+	if body != nil || request.Body == nil || request.Body == http.NoBody {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	if s.Service == "s3" || payloadHash == awsUnsignedPayload {
+		request.Header.Set(amzContentSha256, payloadHash)
+	}
+
+	signedHeaders, canonicalHeaders := s.canonicalHeaders(request)
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		s.canonicalURI(request),
+		awsCanonicalQuery(request),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, s.Service, "aws4_request"}, "/")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		awsAlgorithm,
+		t.Format(awsTimeFormat),
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := awsHMAC([]byte("AWS4"+s.SecretAccessKey), date)
+	key = awsHMAC(key, s.Region)
+	key = awsHMAC(key, s.Service)
+	key = awsHMAC(key, "aws4_request")
+	signature := hex.EncodeToString(awsHMAC(key, stringToSign))
+
+	request.Header.Set(authorization, awsAlgorithm+
+		" Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return nil
+}
+
+// canonicalURI escapes every path segment, twice except for S3.
+func (s *AWSSigner) canonicalURI(request *http.Request) string {
+	path := request.URL.EscapedPath()
+	if len(path) == 0 {
+		return "/"
+	}
+	if s.Service == "s3" {
+		return path
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = awsEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func awsCanonicalQuery(request *http.Request) string {
+	query := request.URL.Query()
+	pairs := make([]string, 0, len(query))
+	for k, vv := range query {
+		for _, v := range vv {
+			pairs = append(pairs, awsEscape(k)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+func (s *AWSSigner) canonicalHeaders(request *http.Request) (signed, canonical string) {
+	host := request.Host
+	if len(host) == 0 {
+		host = request.URL.Host
+	}
+	headers := map[string]string{"host": host}
+	for k, vv := range request.Header {
+		if awsIgnoredHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		values := make([]string, len(vv))
+		for i, v := range vv {
+			values[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[strings.ToLower(k)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// awsEscape escapes everything but the RFC 3986 unreserved characters.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+	}
+	return b.String()
+}
+
+func awsHMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
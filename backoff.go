@@ -6,23 +6,45 @@ import (
 	"time"
 )
 
-type backoffStrategy interface {
-	backoff(retry int) time.Duration
+// BackoffAttempt describes the retry about to happen.
+type BackoffAttempt struct {
+	// Number is the number of the retry, starting at 1.
+	Number int
+	// PreviousDelay is the delay before the previous retry, zero for the first retry.
+	PreviousDelay time.Duration
+	// Response is the last response, it may be nil or have a zero status code if there was none.
+	Response *Response
+	// Err is the last error.
+	Err error
+}
+
+// BackoffStrategy tells how long to wait before a retry.
+// Implementations must be safe for concurrent use.
+type BackoffStrategy interface {
+	Backoff(attempt *BackoffAttempt) time.Duration
+}
+
+// BackoffFunc is an adapter to use an ordinary function as a BackoffStrategy.
+type BackoffFunc func(attempt *BackoffAttempt) time.Duration
+
+// Backoff calls f(attempt).
+func (f BackoffFunc) Backoff(attempt *BackoffAttempt) time.Duration {
+	return f(attempt)
 }
 
 type linearBackoffStrategy struct {
 	slope time.Duration
 }
 
-func (stg linearBackoffStrategy) backoff(retry int) time.Duration {
-	return time.Duration(retry) * stg.slope
+func (stg linearBackoffStrategy) Backoff(attempt *BackoffAttempt) time.Duration {
+	return time.Duration(attempt.Number) * stg.slope
 }
 
 type constantBackOffStrategy struct {
 	interval time.Duration
 }
 
-func (stg constantBackOffStrategy) backoff(retry int) time.Duration {
+func (stg constantBackOffStrategy) Backoff(*BackoffAttempt) time.Duration {
 	return stg.interval
 }
 
@@ -42,16 +64,16 @@ type exponentialBackoffStrategy struct {
 	exponentialBackoff
 }
 
-func (stg exponentialBackoffStrategy) backoff(retry int) time.Duration {
-	return time.Duration(stg.expo(retry))
+func (stg exponentialBackoffStrategy) Backoff(attempt *BackoffAttempt) time.Duration {
+	return time.Duration(stg.expo(attempt.Number))
 }
 
 type exponentialBackoffEqualJitterStrategy struct {
 	exponentialBackoff
 }
 
-func (stg exponentialBackoffEqualJitterStrategy) backoff(retry int) time.Duration {
-	v := stg.expo(retry)
+func (stg exponentialBackoffEqualJitterStrategy) Backoff(attempt *BackoffAttempt) time.Duration {
+	v := stg.expo(attempt.Number)
 	u := uniform(0, v/2.0)
 	return time.Duration(v/2.0 + u)
 }
@@ -60,15 +82,14 @@ type exponentialBackoffFullJitterStrategy struct {
 	exponentialBackoff
 }
 
-func (stg exponentialBackoffFullJitterStrategy) backoff(retry int) time.Duration {
-	v := stg.expo(retry)
+func (stg exponentialBackoffFullJitterStrategy) Backoff(attempt *BackoffAttempt) time.Duration {
+	v := stg.expo(attempt.Number)
 	u := uniform(0, v)
 	return time.Duration(u)
 }
 
 type exponentialBackoffDecorrelatedJitterStrategy struct {
 	exponentialBackoff
-	sleep time.Duration
 }
 
 // uniform returns a number in [min, max)
@@ -76,10 +97,15 @@ func uniform(min, max float64) float64 {
 	return min + rand.Float64()*(max-min)
 }
 
-func (stg exponentialBackoffDecorrelatedJitterStrategy) backoff(retry int) time.Duration {
+// Backoff computes sleep = min(cap, random_between(base, sleep * 3)),
+// the previous sleep comes from the attempt so the strategy can be shared by concurrent requests.
+func (stg exponentialBackoffDecorrelatedJitterStrategy) Backoff(attempt *BackoffAttempt) time.Duration {
 	c := float64(stg.cap)
 	b := float64(stg.base)
-	s := float64(stg.sleep)
+	s := float64(attempt.PreviousDelay)
+	if s < b {
+		s = b
+	}
 	u := uniform(b, 3*s)
 	s = math.Min(c, u)
 	return time.Duration(s)
@@ -0,0 +1,51 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoffDecorrelatedJitterStrategy_Backoff(t *testing.T) {
+	stg := exponentialBackoffDecorrelatedJitterStrategy{
+		exponentialBackoff{
+			base: time.Millisecond,
+			cap:  time.Second,
+		},
+	}
+	attempt := &BackoffAttempt{Number: 1}
+	for i := 0; i < 20; i++ {
+		d := stg.Backoff(attempt)
+		upper := 3 * attempt.PreviousDelay
+		if upper < 3*time.Millisecond {
+			upper = 3 * time.Millisecond
+		}
+		assert(t, d >= time.Millisecond && d <= time.Second && d <= upper, "%d: unexpected backoff %s", i, d)
+		attempt.Number++
+		attempt.PreviousDelay = d
+	}
+}
+
+func TestWithBackoffStrategy(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	var attempts []BackoffAttempt
+	stg := BackoffFunc(func(attempt *BackoffAttempt) time.Duration {
+		attempts = append(attempts, *attempt)
+		return time.Duration(attempt.Number) * time.Millisecond
+	})
+	c, err := New(WithBaseURL(ts.URL), WithRetry(2), WithRetryStatusCodes(http.StatusBadGateway), WithBackoffStrategy(stg))
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+
+	assert(t, len(attempts) == 2, "unexpected attempts %d", len(attempts))
+	assert(t, attempts[0].Number == 1 && attempts[0].PreviousDelay == 0, "unexpected first attempt %+v", attempts[0])
+	assert(t, attempts[1].Number == 2 && attempts[1].PreviousDelay == time.Millisecond, "unexpected second attempt %+v", attempts[1])
+	assert(t, attempts[1].Response.StatusCode() == http.StatusBadGateway, "unexpected response")
+}
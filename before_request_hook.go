@@ -9,6 +9,7 @@ type BeforeRequestHook func(cast *Cast, request *Request) error
 
 var defaultBeforeRequestHooks = []BeforeRequestHook{
 	finalizePathIfAny,
+	resolveCodec,
 	setRequestHeader,
 }
 
@@ -16,12 +17,12 @@ func finalizePathIfAny(cast *Cast, request *Request) error {
 	if len(request.pathParam) > 0 {
 		tpl, err := uritemplates.Parse(request.path)
 		if err != nil {
-			cast.Logger().WithError(err).Error("uritemplates.Parse: ", request.path)
+			cast.logger.Error("uritemplates.Parse", cast.errField(err), field("path", request.path))
 			return err
 		}
 		request.path, err = tpl.Expand(request.pathParam)
 		if err != nil {
-			cast.Logger().WithError(err).Error("tpl.Expand: ", request.pathParam)
+			cast.logger.Error("tpl.Expand", cast.errField(err), field("path_param", request.pathParam))
 			return err
 		}
 	}
@@ -32,5 +33,31 @@ func setRequestHeader(cast *Cast, request *Request) error {
 	if request.body != nil && len(request.body.ContentType()) > 0 {
 		request.SetHeader(contentType, request.body.ContentType())
 	}
+	if request.body != nil && len(request.compression) > 0 {
+		if err := checkEncoding(request.compression); err != nil {
+			return err
+		}
+		request.SetHeader(contentEncoding, request.compression)
+	}
+	if len(cast.acceptEncoding) > 0 && !request.HeaderExist(acceptEncoding) {
+		request.SetHeader(acceptEncoding, cast.acceptEncoding)
+	}
 	return nil
 }
+
+func resolveCodec(cast *Cast, request *Request) error {
+	request.codecs = cast.codecs
+	var err error
+	switch body := request.body.(type) {
+	case *requestJSONBody:
+		body.codec, err = cast.codecs.lookup(applicationJSON)
+	case *requestXMLBody:
+		body.codec, err = cast.codecs.lookup(applicationXML)
+	case *requestCodecBody:
+		body.codec, err = cast.codecs.lookup(body.contentType)
+	}
+	if err != nil {
+		cast.logger.Error("cast.codecs.lookup", cast.errField(err))
+	}
+	return err
+}
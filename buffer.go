@@ -1,21 +0,0 @@
-package cast
-
-import (
-	"bytes"
-	"sync"
-)
-
-var bufferPool = &sync.Pool{
-	New: func() interface{} {
-		return &bytes.Buffer{}
-	},
-}
-
-func getBuffer() *bytes.Buffer {
-	return bufferPool.Get().(*bytes.Buffer)
-}
-
-func putBuffer(buffer *bytes.Buffer) {
-	buffer.Reset()
-	bufferPool.Put(buffer)
-}
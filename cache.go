@@ -0,0 +1,362 @@
+package cast
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a stored response.
+type CacheEntry struct {
+	StatusCode int
+	Proto      string
+	Header     http.Header
+	Body       []byte
+	// RequestHeader holds the request values of the headers named by the Vary response header.
+	RequestHeader http.Header
+	StoredAt      time.Time
+}
+
+// CacheStore stores responses by key, implementations must be safe for concurrent use.
+type CacheStore interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+type memoryCacheItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+type memoryCacheStore struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[string]*list.Element
+}
+
+// NewMemoryCacheStore returns an in-memory LRU store holding at most capacity responses.
+func NewMemoryCacheStore(capacity int) CacheStore {
+	return &memoryCacheStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+func (s *memoryCacheStore) Get(key string) (*CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+	s.ll.MoveToFront(e)
+	return e.Value.(*memoryCacheItem).entry, true
+}
+
+func (s *memoryCacheStore) Set(key string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.ll.MoveToFront(e)
+		e.Value.(*memoryCacheItem).entry = entry
+		return
+	}
+	s.items[key] = s.ll.PushFront(&memoryCacheItem{key: key, entry: entry})
+	for s.capacity > 0 && s.ll.Len() > s.capacity {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryCacheItem).key)
+	}
+}
+
+func (s *memoryCacheStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.items[key]; ok {
+		s.ll.Remove(e)
+		delete(s.items, key)
+	}
+}
+
+type cacheControl map[string]string
+
+func parseCacheControl(h http.Header) cacheControl {
+	cc := cacheControl{}
+	for _, part := range strings.Split(h.Get("Cache-Control"), ",") {
+		part = strings.TrimSpace(part)
+		if len(part) == 0 {
+			continue
+		}
+		if i := strings.IndexByte(part, '='); i >= 0 {
+			cc[strings.ToLower(part[:i])] = strings.Trim(part[i+1:], `"`)
+		} else {
+			cc[strings.ToLower(part)] = ""
+		}
+	}
+	return cc
+}
+
+func (cc cacheControl) has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+func (cc cacheControl) seconds(directive string) (time.Duration, bool) {
+	v, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+func cacheKey(r *http.Request) string {
+	return r.Method + " " + r.URL.String()
+}
+
+// varyIndexKey stores the header names a url varies on, along with when its variants were
+// last invalidated.
+func varyIndexKey(key string) string {
+	return key + " vary"
+}
+
+// variantKey adds the normalized request values of the vary header names to key.
+func variantKey(key string, names, h http.Header) string {
+	keys := make([]string, 0, len(names))
+	for name := range names {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(key)
+	for _, name := range keys {
+		values := make([]string, len(h[name]))
+		for i, v := range h[name] {
+			values[i] = strings.TrimSpace(v)
+		}
+		b.WriteString("\n" + name + ":" + strings.Join(values, ","))
+	}
+	return b.String()
+}
+
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusGone:                 true,
+}
+
+// lifetime returns how long the entry is fresh after it was stored.
+func (entry *CacheEntry) lifetime() time.Duration {
+	cc := parseCacheControl(entry.Header)
+	if cc.has("no-cache") {
+		return 0
+	}
+	if maxAge, ok := cc.seconds("max-age"); ok {
+		return maxAge
+	}
+	if expires := entry.Header.Get("Expires"); len(expires) > 0 {
+		t, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		date, err := http.ParseTime(entry.Header.Get("Date"))
+		if err != nil {
+			date = entry.StoredAt
+		}
+		return t.Sub(date)
+	}
+	return 0
+}
+
+func (entry *CacheEntry) age(now time.Time) time.Duration {
+	age := now.Sub(entry.StoredAt)
+	if n, err := strconv.ParseInt(entry.Header.Get("Age"), 10, 64); err == nil && n > 0 {
+		age += time.Duration(n) * time.Second
+	}
+	return age
+}
+
+func (entry *CacheEntry) fresh(now time.Time) bool {
+	return entry.age(now) < entry.lifetime()
+}
+
+func (entry *CacheEntry) varyMatches(h http.Header) bool {
+	for name, vv := range entry.RequestHeader {
+		if strings.Join(vv, ",") != strings.Join(h[name], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+func (entry *CacheEntry) response(request *Request) *Response {
+	body := make([]byte, len(entry.Body))
+	copy(body, entry.Body)
+	return &Response{
+		request: request,
+		rawResponse: &http.Response{
+			Status:        fmt.Sprintf("%d %s", entry.StatusCode, http.StatusText(entry.StatusCode)),
+			StatusCode:    entry.StatusCode,
+			Proto:         entry.Proto,
+			Header:        entry.Header.Clone(),
+			ContentLength: int64(len(body)),
+			Request:       request.rawRequest,
+		},
+		statusCode: entry.StatusCode,
+		body:       body,
+		fromCache:  true,
+	}
+}
+
+func newCacheEntry(resp *Response, now time.Time) (*CacheEntry, bool) {
+	if !cacheableStatusCodes[resp.statusCode] || resp.rawResponse == nil {
+		return nil, false
+	}
+	cc := parseCacheControl(resp.rawResponse.Header)
+	if cc.has("no-store") {
+		return nil, false
+	}
+	entry := &CacheEntry{
+		StatusCode:    resp.statusCode,
+		Proto:         resp.rawResponse.Proto,
+		Header:        resp.rawResponse.Header.Clone(),
+		Body:          append([]byte(nil), resp.body...),
+		RequestHeader: make(http.Header),
+		StoredAt:      now,
+	}
+	for _, vary := range entry.Header["Vary"] {
+		for _, name := range strings.Split(vary, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			if name == "*" {
+				return nil, false
+			}
+			if len(name) > 0 {
+				entry.RequestHeader[name] = resp.request.rawRequest.Header[name]
+			}
+		}
+	}
+	hasValidator := len(entry.Header.Get("ETag")) > 0 || len(entry.Header.Get("Last-Modified")) > 0
+	if entry.lifetime() <= 0 && !hasValidator {
+		return nil, false
+	}
+	return entry, true
+}
+
+// cachedReply serves GET and HEAD requests from the cache, revalidating stale entries,
+// and evicts the url after a successful unsafe request.
+func (c *Cast) cachedReply(request *Request) (*Response, error) {
+	raw := request.rawRequest
+	reqCC := parseCacheControl(raw.Header)
+	if (raw.Method != http.MethodGet && raw.Method != http.MethodHead) || request.stream || reqCC.has("no-store") {
+		rep, err := c.genReply(request)
+		if err == nil && raw.Method != http.MethodGet && raw.Method != http.MethodHead && rep.Success() {
+			u := raw.URL.String()
+			c.invalidate(http.MethodGet + " " + u)
+			c.invalidate(http.MethodHead + " " + u)
+		}
+		return rep, err
+	}
+
+	base := cacheKey(raw)
+	key := base
+	index, varies := c.cache.Get(varyIndexKey(base))
+	if varies {
+		key = variantKey(base, index.RequestHeader, raw.Header)
+	}
+	entry, ok := c.cache.Get(key)
+	if ok && (!entry.varyMatches(raw.Header) || varies && entry.StoredAt.Before(index.StoredAt)) {
+		ok = false
+	}
+	if ok && entry.fresh(time.Now()) && !reqCC.has("no-cache") {
+		if maxAge, set := reqCC.seconds("max-age"); !set || entry.age(time.Now()) < maxAge {
+			closeBody(raw.Body)
+			return entry.response(request), nil
+		}
+	}
+
+	var revalidating bool
+	if ok && len(raw.Header.Get("If-None-Match")) == 0 && len(raw.Header.Get("If-Modified-Since")) == 0 {
+		if etag := entry.Header.Get("ETag"); len(etag) > 0 {
+			raw.Header.Set("If-None-Match", etag)
+			revalidating = true
+		}
+		if lastModified := entry.Header.Get("Last-Modified"); len(lastModified) > 0 {
+			raw.Header.Set("If-Modified-Since", lastModified)
+			revalidating = true
+		}
+	}
+
+	rep, err := c.genReply(request)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if revalidating && rep.statusCode == http.StatusNotModified {
+		updated := *entry
+		updated.Header = entry.Header.Clone()
+		for k, vv := range rep.Header() {
+			updated.Header[k] = vv
+		}
+		updated.StoredAt = now
+		c.cache.Set(key, &updated)
+		return updated.response(request), nil
+	}
+	if newEntry, storable := newCacheEntry(rep, now); storable {
+		c.store(base, newEntry)
+	}
+	return rep, nil
+}
+
+// store sets entry under key, or under its variant key if the response varies.
+func (c *Cast) store(key string, entry *CacheEntry) {
+	if len(entry.RequestHeader) == 0 {
+		c.cache.Delete(varyIndexKey(key))
+		c.cache.Set(key, entry)
+		return
+	}
+	index, ok := c.cache.Get(varyIndexKey(key))
+	if !ok || !sameHeaderNames(index.RequestHeader, entry.RequestHeader) {
+		index = &CacheEntry{RequestHeader: make(http.Header), StoredAt: entry.StoredAt}
+		for name := range entry.RequestHeader {
+			index.RequestHeader[name] = nil
+		}
+		c.cache.Set(varyIndexKey(key), index)
+	}
+	c.cache.Delete(key)
+	c.cache.Set(variantKey(key, index.RequestHeader, entry.RequestHeader), entry)
+}
+
+// invalidate evicts key and every variant of it.
+func (c *Cast) invalidate(key string) {
+	c.cache.Delete(key)
+	if index, ok := c.cache.Get(varyIndexKey(key)); ok {
+		c.cache.Set(varyIndexKey(key), &CacheEntry{RequestHeader: index.RequestHeader, StoredAt: time.Now()})
+	}
+}
+
+func sameHeaderNames(a, b http.Header) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name := range a {
+		if _, ok := b[name]; !ok {
+			return false
+		}
+	}
+	return true
+}
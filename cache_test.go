@@ -0,0 +1,123 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCast_CacheMaxAge(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithCache(NewMemoryCacheStore(10)))
+	ok(t, err)
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/config"))
+		ok(t, err)
+		assert(t, resp.String() == "config", "%d: unexpected body", i)
+		assert(t, resp.FromCache() == (i > 0), "%d: unexpected FromCache()", i)
+	}
+	assert(t, atomic.LoadInt32(&hits) == 1, "unexpected hits: %d", hits)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().Post().WithPath("/config"))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/config"))
+	ok(t, err)
+	assert(t, !resp.FromCache(), "expected cache invalidated by POST")
+}
+
+func TestCast_CacheRevalidate(t *testing.T) {
+	var hits, notModified int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithCache(NewMemoryCacheStore(10)))
+	ok(t, err)
+
+	for i := 0; i < 2; i++ {
+		resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/config"))
+		ok(t, err)
+		assert(t, resp.StatusCode() == http.StatusOK, "%d: unexpected status code %d", i, resp.StatusCode())
+		assert(t, resp.String() == "config", "%d: unexpected body", i)
+		assert(t, resp.FromCache() == (i > 0), "%d: unexpected FromCache()", i)
+	}
+	assert(t, hits == 2 && notModified == 1, "unexpected hits: %d, %d", hits, notModified)
+}
+
+func TestMemoryCacheStore_Evict(t *testing.T) {
+	store := NewMemoryCacheStore(2)
+	store.Set("a", &CacheEntry{})
+	store.Set("b", &CacheEntry{})
+	_, _ = store.Get("a")
+	store.Set("c", &CacheEntry{})
+
+	_, okA := store.Get("a")
+	_, okB := store.Get("b")
+	assert(t, okA && !okB, "unexpected eviction")
+}
+
+func TestCast_CacheVary(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		_, _ = w.Write([]byte(r.Header.Get("Accept-Language")))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithCache(NewMemoryCacheStore(10)))
+	ok(t, err)
+	get := func(language string) {
+		resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/").SetHeader("Accept-Language", language))
+		ok(t, err)
+		assert(t, resp.String() == language, "unexpected body %s for %s", resp.String(), language)
+	}
+	for _, language := range []string{"en", "fr", "en", "fr"} {
+		get(language)
+	}
+	assert(t, atomic.LoadInt32(&hits) == 2, "expected a variant per language, got %d hits", hits)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().Post().WithPath("/"))
+	ok(t, err)
+	get("en")
+	get("fr")
+	assert(t, atomic.LoadInt32(&hits) == 5, "expected every variant to be invalidated, got %d hits", hits)
+}
+
+func TestCast_CacheBodyCopied(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		_, _ = w.Write([]byte("config"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithCache(NewMemoryCacheStore(10)))
+	ok(t, err)
+
+	for i := 0; i < 3; i++ {
+		resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/config"))
+		ok(t, err)
+		assert(t, resp.String() == "config", "%d: unexpected body %s", i, resp.String())
+		copy(resp.Body(), "MUTATED!")
+	}
+}
@@ -0,0 +1,107 @@
+package cast
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// ErrEmptyBody is returned by Call when a response expected to carry a body has none.
+const ErrEmptyBody Error = "cast: empty response body"
+
+// StatusError is returned by CallE for a non-2xx response, with the error body decoded into Value.
+type StatusError[E any] struct {
+	*HTTPError
+	Value E
+}
+
+// Unwrap returns the underlying *HTTPError.
+func (err *StatusError[E]) Unwrap() error {
+	return err.HTTPError
+}
+
+// Call sends request and decodes a 2xx response body into T according to its Content-Type,
+// JSON being assumed when there is none. A non-2xx response is returned as an *HTTPError.
+// T may be []byte or string to get the raw body.
+func Call[T any](ctx context.Context, c *Cast, request *Request) (T, *Response, error) {
+	var out T
+	resp, err := doTyped(ctx, c, request)
+	if err != nil {
+		return out, nil, err
+	}
+	if !resp.Success() {
+		return out, resp, c.newHTTPError(resp)
+	}
+	err = decodeResponse(resp, &out)
+	return out, resp, err
+}
+
+// CallE is like Call, but decodes a non-2xx response body into E, returned as a *StatusError[E].
+// If the body cannot be decoded into E, DecodeErr is set and Value is left as is.
+func CallE[T, E any](ctx context.Context, c *Cast, request *Request) (T, *Response, error) {
+	var out T
+	resp, err := doTyped(ctx, c, request)
+	if err != nil {
+		return out, nil, err
+	}
+	if !resp.Success() {
+		if err := readStream(resp); err != nil {
+			return out, resp, err
+		}
+		statusErr := &StatusError[E]{HTTPError: c.newHTTPError(resp)}
+		if len(resp.body) > 0 {
+			if err := resp.Decode(&statusErr.Value); err != nil {
+				statusErr.DecodeErr = err
+			}
+		}
+		return out, resp, statusErr
+	}
+	err = decodeResponse(resp, &out)
+	return out, resp, err
+}
+
+// doTyped sends request and leaves a non-2xx response to Call and CallE,
+// even with WithErrorOnStatus or Request.ExpectSuccess.
+func doTyped(ctx context.Context, c *Cast, request *Request) (*Response, error) {
+	request.typedCall = true
+	defer func() {
+		request.typedCall = false
+	}()
+	return c.Do(ctx, request)
+}
+
+func decodeResponse(resp *Response, v interface{}) error {
+	if err := readStream(resp); err != nil {
+		return err
+	}
+	switch t := v.(type) {
+	case *[]byte:
+		*t = resp.body
+		return nil
+	case *string:
+		*t = string(resp.body)
+		return nil
+	}
+	if len(resp.body) == 0 {
+		if resp.statusCode == http.StatusNoContent || resp.Method() == http.MethodHead {
+			return nil
+		}
+		return ErrEmptyBody
+	}
+	return resp.Decode(v)
+}
+
+// readStream reads a streamed body so that it can be decoded.
+func readStream(resp *Response) error {
+	if resp.stream == nil {
+		return nil
+	}
+	body, err := io.ReadAll(resp.stream)
+	closeErr := resp.Close()
+	resp.stream = nil
+	if err != nil {
+		return err
+	}
+	resp.body = body
+	return closeErr
+}
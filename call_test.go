@@ -0,0 +1,79 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type callUser struct {
+	Name string `json:"name" xml:"name"`
+}
+
+type callError struct {
+	Code string `json:"code"`
+}
+
+func TestCall(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/json":
+			w.Header().Set("Content-Type", "application/problem+json")
+			_, _ = w.Write([]byte(`{"name":"cast"}`))
+		case "/xml":
+			w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+			_, _ = w.Write([]byte(`<user><name>cast</name></user>`))
+		case "/empty":
+		case "/missing":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"user_not_found"}`))
+		case "/unavailable":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`<html>maintenance</html>`))
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+
+	for _, path := range []string{"/json", "/xml"} {
+		user, resp, err := Call[callUser](context.TODO(), c, c.NewRequest().WithPath(path))
+		ok(t, err)
+		assert(t, resp.StatusOk() && user.Name == "cast", "%s: unexpected user %+v", path, user)
+	}
+
+	body, _, err := Call[string](context.TODO(), c, c.NewRequest().WithPath("/xml"))
+	ok(t, err)
+	assert(t, body == `<user><name>cast</name></user>`, "unexpected body %s", body)
+
+	_, _, err = Call[callUser](context.TODO(), c, c.NewRequest().WithPath("/empty"))
+	assert(t, errors.Is(err, ErrEmptyBody), "unexpected error %v", err)
+
+	_, _, err = Call[callUser](context.TODO(), c, c.NewRequest().WithPath("/missing"))
+	assert(t, IsNotFound(err), "unexpected error %v", err)
+
+	_, resp, err := CallE[callUser, callError](context.TODO(), c, c.NewRequest().WithPath("/missing"))
+	var statusErr *StatusError[callError]
+	assert(t, errors.As(err, &statusErr), "unexpected error %v", err)
+	assert(t, statusErr.Value.Code == "user_not_found", "unexpected error body %+v", statusErr.Value)
+	assert(t, IsNotFound(err) && resp.StatusCode() == http.StatusNotFound, "unexpected error %v", err)
+
+	_, _, err = CallE[callUser, callError](context.TODO(), c, c.NewRequest().WithPath("/unavailable"))
+	assert(t, errors.As(err, &statusErr), "unexpected error %v", err)
+	assert(t, statusErr.DecodeErr != nil && statusErr.StatusCode == http.StatusServiceUnavailable,
+		"expected a decode error, got %v", statusErr.DecodeErr)
+
+	c, err = New(WithBaseURL(ts.URL), WithErrorOnStatus())
+	ok(t, err)
+	_, resp, err = CallE[callUser, callError](context.TODO(), c, c.NewRequest().WithPath("/missing"))
+	assert(t, errors.As(err, &statusErr), "unexpected error %v with WithErrorOnStatus", err)
+	assert(t, statusErr.Value.Code == "user_not_found", "unexpected error body %+v", statusErr.Value)
+	assert(t, resp.StatusCode() == http.StatusNotFound, "unexpected status code %d", resp.StatusCode())
+	_, _, err = Call[callUser](context.TODO(), c, c.NewRequest().WithPath("/missing").ExpectSuccess())
+	assert(t, IsNotFound(err), "unexpected error %v", err)
+}
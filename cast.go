@@ -1,20 +1,18 @@
 package cast
 
 import (
-	"bytes"
 	"context"
 	"crypto/tls"
-	"errors"
 	"github.com/cep21/circuit/v3"
 	"github.com/cep21/circuit/v3/closers/hystrix"
-	"github.com/opentracing/opentracing-go"
+	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -23,23 +21,47 @@ const (
 
 // Cast provides a set of rules to its request.
 type Cast struct {
-	client             *http.Client
-	baseURL            string
-	header             http.Header
-	basicAuth          *BasicAuth
-	bearerToken        string
-	cookies            []*http.Cookie
-	retry              int
-	stg                backoffStrategy
-	beforeRequestHooks []BeforeRequestHook
-	requestHooks       []RequestHook
-	responseHooks      []responseHook
-	retryHooks         []RetryHook
-	dumpFlag           int
-	httpClientTimeout  time.Duration
-	logger             *logrus.Logger
-	h                  circuit.Manager
-	defaultCircuitName string
+	client              *http.Client
+	roundTripper        http.RoundTripper
+	transport           *http.Transport // owned by cast, safe to change
+	transportConfig     transportConfig
+	tlsConfig           *tls.Config
+	cache               CacheStore
+	rateLimiter         *rate.Limiter
+	hostLimiters        map[string]*rate.Limiter
+	circuitLimiters     map[string]*rate.Limiter
+	rateLimitMode       RateLimitMode
+	retryStatusCodes    map[int]bool
+	retryIdempotentOnly bool
+	maxRetryAfter       time.Duration
+	retryBudget         *retryBudget
+	maxElapsedTime      time.Duration
+	tracer              Tracer
+	metrics             MetricsRecorder
+	redactor            *redactor
+	recorder            *recorder
+	errorOnStatus       bool
+	codecs              codecRegistry
+	acceptEncoding      string
+	signer              Signer
+	baseURL             string
+	header              http.Header
+	basicAuth           *BasicAuth
+	bearerToken         string
+	cookies             []*http.Cookie
+	retry               int
+	stg                 BackoffStrategy
+	beforeRequestHooks  []BeforeRequestHook
+	requestHooks        []RequestHook
+	responseHooks       []responseHook
+	retryHooks          []RetryHook
+	dumpFlag            DumpFlag
+	dumpBodyLimit       int
+	httpClientTimeout   time.Duration
+	logger              Logger
+	logrus              *logrus.Logger
+	h                   circuit.Manager
+	defaultCircuitName  string
 }
 
 // New returns an instance of Cast
@@ -50,15 +72,24 @@ func New(sl ...Setter) (*Cast, error) {
 	c.requestHooks = defaultRequestHooks
 	c.responseHooks = defaultResponseHooks
 	c.retryHooks = defaultRetryHooks
-	c.dumpFlag = fStd
+	c.maxRetryAfter = defaultMaxRetryAfter
+	c.tracer = NewOpentracingTracer(nil)
+	c.redactor = newRedactor()
+	c.codecs = defaultCodecs.clone()
+	c.dumpFlag = DumpStd
+	c.dumpBodyLimit = defaultDumpBodyLimit
 	c.httpClientTimeout = 10 * time.Second
-	c.logger = logrus.New()
-	c.logger.SetFormatter(&logrus.JSONFormatter{
+	c.transportConfig = defaultTransportConfig()
+	c.hostLimiters = make(map[string]*rate.Limiter)
+	c.circuitLimiters = make(map[string]*rate.Limiter)
+	c.logrus = logrus.New()
+	c.logrus.SetFormatter(&logrus.JSONFormatter{
 		TimestampFormat: "2006-01-02 15:04:05",
 	})
-	c.logger.SetReportCaller(true)
-	c.logger.SetOutput(os.Stderr)
-	c.logger.SetLevel(logrus.InfoLevel)
+	c.logrus.SetReportCaller(true)
+	c.logrus.SetOutput(os.Stderr)
+	c.logrus.SetLevel(logrus.InfoLevel)
+	c.logger = NewLogrusLogger(c.logrus)
 
 	configuration := hystrix.Factory{
 		ConfigureOpener: hystrix.ConfigureOpener{
@@ -76,7 +107,7 @@ func New(sl ...Setter) (*Cast, error) {
 	}
 
 	c.h = circuit.Manager{
-		DefaultCircuitProperties: []circuit.CommandPropertiesConstructor{configuration.Configure},
+		DefaultCircuitProperties: []circuit.CommandPropertiesConstructor{configuration.Configure, c.circuitMetricsConfig},
 	}
 
 	for _, s := range sl {
@@ -85,36 +116,28 @@ func New(sl ...Setter) (*Cast, error) {
 		}
 	}
 
-	c.client = &http.Client{
-		Transport: &http.Transport{
-			Proxy: http.ProxyFromEnvironment,
-			DialContext: (&net.Dialer{
-				Timeout:   30 * time.Second,
-				KeepAlive: 30 * time.Second,
-				DualStack: true,
-			}).DialContext,
-			MaxIdleConns:          2000,
-			MaxIdleConnsPerHost:   2000,
-			IdleConnTimeout:       90 * time.Second,
-			TLSHandshakeTimeout:   10 * time.Second,
-			ExpectContinueTimeout: 1 * time.Second,
-		},
-		Timeout: c.httpClientTimeout,
+	client, err := c.buildClient()
+	if err != nil {
+		return nil, err
 	}
+	c.client = client
 
 	return c, nil
 }
 
 // SetInsecureSkipVerify set the InsecureSkipVerify value.
+// A transport supplied by the caller is cloned first and left untouched.
 func (c *Cast) SetInsecureSkipVerify(v bool) error {
-	t, ok := c.client.Transport.(*http.Transport)
-	if !ok {
-		return errors.New("http client type assertion failed")
+	t, err := c.ownTransport()
+	if err != nil {
+		return err
 	}
-	if t.TLSClientConfig == nil {
-		t.TLSClientConfig = &tls.Config{}
+	config := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		config = t.TLSClientConfig.Clone()
 	}
-	t.TLSClientConfig.InsecureSkipVerify = true
+	config.InsecureSkipVerify = v
+	t.TLSClientConfig = config
 	return nil
 }
 
@@ -125,12 +148,24 @@ func (c *Cast) NewRequest() *Request {
 
 // Do initiates a request.
 func (c *Cast) Do(ctx context.Context, request *Request) (*Response, error) {
-	body, err := request.ReqBody()
-	if err != nil {
-		c.logger.WithError(err).Error("request.reqBody")
-		return nil, err
+	ctx, span := c.tracer.Start(ctx, "HTTP "+request.method)
+	rep, err := c.do(ctx, request)
+	span.SetAttribute("http.method", request.method)
+	if request.rawRequest != nil {
+		span.SetAttribute("http.url", c.redactor.url(request.rawRequest.URL))
 	}
+	c.endSpan(span, rep, err)
+	return rep, err
+}
 
+func (c *Cast) do(ctx context.Context, request *Request) (*Response, error) {
+	if len(request.pathTemplate) == 0 {
+		request.pathTemplate = request.path
+	}
+	request.timings = nil
+	request.start = time.Now()
+
+	var err error
 	for _, hook := range c.beforeRequestHooks {
 		err = hook(c, request)
 		if err != nil {
@@ -138,136 +173,344 @@ func (c *Cast) Do(ctx context.Context, request *Request) (*Response, error) {
 		}
 	}
 
-	tracer := opentracing.GlobalTracer()
-	span := opentracing.SpanFromContext(ctx)
-	if tracer != nil && span != nil {
-		err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(request.header))
-		if err != nil {
-			c.logger.WithError(err).Error("tracer.Inject")
-			return nil, err
-		}
+	request.traceCtx = ctx
+
+	body, err := request.bodyReader()
+	if err != nil {
+		c.logger.Error("request.bodyReader", c.errField(err))
+		return nil, err
 	}
 
-	request.rawRequest, err = http.NewRequestWithContext(ctx, request.method, c.baseURL+request.path, bytes.NewReader(body))
+	request.rawRequest, err = http.NewRequestWithContext(ctx, request.method, c.baseURL+request.path, body)
 	if err != nil {
-		c.logger.WithError(err).Error("http.NewRequest")
+		c.logger.Error("http.NewRequest", c.errField(err))
+		closeBody(body)
 		return nil, err
 	}
+	if _, ok := request.body.(streamRequestBody); ok {
+		request.rawRequest.GetBody = func() (io.ReadCloser, error) {
+			body, err := request.bodyReader()
+			if err != nil {
+				return nil, err
+			}
+			return body.(io.ReadCloser), nil
+		}
+	}
 
 	for _, hook := range c.requestHooks {
 		err = hook(c, request)
 		if err != nil {
+			closeBody(request.rawRequest.Body)
 			return nil, err
 		}
 	}
 
-	rep, err := c.genReply(request)
+	var (
+		rep   *Response
+		start = time.Now()
+	)
+	if c.metrics != nil {
+		c.metrics.InFlight(request.labels(), 1)
+	}
+	if c.cache != nil {
+		rep, err = c.cachedReply(request)
+	} else {
+		rep, err = c.genReply(request)
+	}
+	if c.metrics != nil {
+		c.metrics.InFlight(request.labels(), -1)
+		c.metrics.ObserveRequest(request.metrics(rep, err, start))
+	}
 	if err != nil {
 		return nil, err
 	}
 
 	for _, hook := range c.responseHooks {
 		if err := hook(c, rep); err != nil {
-			c.logger.WithError(err).Error("hook(c, resp)")
+			c.logger.Error("hook(c, resp)", c.errField(err))
+			_ = rep.Close()
 			return nil, err
 		}
 	}
 
+	if (c.errorOnStatus || request.expectSuccess) && !request.typedCall && !rep.Success() {
+		return nil, c.newHTTPError(rep)
+	}
+
 	return rep, nil
 }
 
 func (c *Cast) genReply(request *Request) (*Response, error) {
+	if c.retryBudget != nil {
+		c.retryBudget.recordRequest()
+	}
+
+	start := time.Now()
+	cancel := c.withMaxElapsedTime(request, start)
+	resp, err := c.retryLoop(request, start)
+	if cancel == nil {
+		return resp, err
+	}
+	if err == nil && resp.stream != nil {
+		// the deadline bounds reading the stream as well, released once it is closed.
+		resp.stream = &cancelOnClose{ReadCloser: resp.stream, cancel: cancel}
+		return resp, nil
+	}
+	cancel()
+	return resp, err
+}
+
+// withMaxElapsedTime bounds the request by WithMaxElapsedTime, the returned cancel is nil if it is unset.
+func (c *Cast) withMaxElapsedTime(request *Request, start time.Time) context.CancelFunc {
+	if c.maxElapsedTime <= 0 {
+		return nil
+	}
+	deadline := start.Add(c.maxElapsedTime)
+	ctx, cancel := context.WithDeadline(request.rawRequest.Context(), deadline)
+	request.rawRequest = request.rawRequest.WithContext(ctx)
+	if request.untracedCtx == nil {
+		return cancel
+	}
+	// hedged attempts derive from the untraced context.
+	var cancelUntraced context.CancelFunc
+	request.untracedCtx, cancelUntraced = context.WithDeadline(request.untracedCtx, deadline)
+	return func() {
+		cancel()
+		cancelUntraced()
+	}
+}
+
+// retryLoop sends the request until an attempt is not retried.
+func (c *Cast) retryLoop(request *Request, start time.Time) (*Response, error) {
+	retry := c.retry
+	if request.overrideRetry {
+		retry = request.retry
+	}
+
 	var (
-		count = 0
-		err   error
 		resp  *Response
+		err   error
+		delay time.Duration
+		ok    bool
 	)
-
-	for {
-		if count > c.retry {
-			break
+	for count := 1; ; count++ {
+		var circuitOpen bool
+		resp, circuitOpen, err = c.attempt(request, count)
+		if resp == nil {
+			return nil, err
 		}
-		var (
-			rawResponse *http.Response
-			cb          *circuit.Circuit
-		)
-		if len(request.circuitName) > 0 {
-			cb = c.h.GetCircuit(request.circuitName)
-		} else {
-			cb = c.h.GetCircuit(c.defaultCircuitName)
+		if circuitOpen || count > retry {
+			break
 		}
-		if count >= 1 {
-			var body []byte
-			body, err = request.ReqBody()
-			if err != nil {
-				return nil, err
-			}
-			request.rawRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if delay, ok = c.nextDelay(request, resp, err, count, delay, start); !ok {
+			break
 		}
-		var fallback bool
-		if cb != nil {
-			err = cb.Execute(context.TODO(), func(i context.Context) error {
-				rawResponse, err = c.client.Do(request.rawRequest)
-				if err != nil {
-					fallback = true
-					return err
-				}
-				return nil
-			}, func(i context.Context, e error) error {
-				return e
-			})
-		} else {
-			rawResponse, err = c.client.Do(request.rawRequest)
+		if err := c.backoff(request, resp, delay); err != nil {
+			return nil, err
 		}
-		count++
-		request.prof.requestDone = time.Now().In(time.UTC)
-		request.prof.requestCost = request.prof.requestDone.Sub(request.prof.requestStart)
-		request.prof.receivingDone = time.Now().In(time.UTC)
-		request.prof.receivingCost = request.prof.receivingDone.Sub(request.prof.receivingSart)
+	}
 
-		resp = new(Response)
-		resp.request = request
-		resp.rawResponse = rawResponse
-		if rawResponse != nil {
-			var repBody []byte
-			repBody, err = ioutil.ReadAll(rawResponse.Body)
-			if err != nil {
-				c.logger.WithError(err).Error("ioutil.ReadAll(rawResponse.Body)")
-				return nil, err
-			}
-			err = rawResponse.Body.Close()
-			if err != nil {
-				c.logger.WithError(err).Error("rawResponse.Body.Close()")
-				return nil, err
-			}
-			resp.body = repBody
-			resp.statusCode = rawResponse.StatusCode
-		}
+	if err != nil {
+		c.logger.Error("c.client.Do", append(c.logFields(request), c.errField(err))...)
+		return nil, err
+	}
+	return resp, nil
+}
 
-		if fallback && cb.IsOpen() {
-			break
+// attempt sends the request once. A nil response aborts the request with the error,
+// otherwise the error of the attempt is left to the retry policy.
+// circuitOpen reports that the attempt failed and opened the circuit.
+func (c *Cast) attempt(request *Request, count int) (resp *Response, circuitOpen bool, err error) {
+	cb := c.getCircuit(request)
+	if err = c.waitRateLimit(request); err != nil {
+		c.logger.Error("c.waitRateLimit", c.errField(err))
+		closeBody(request.rawRequest.Body)
+		return nil, false, err
+	}
+	if count > 1 {
+		if err = request.rewindBody(); err != nil {
+			c.logger.Error("request.bodyReader", c.errField(err))
+			return nil, false, err
 		}
+	}
+	attemptCtx, attemptSpan := c.tracer.Start(request.traceCtx, "HTTP "+request.method+" attempt")
+	attemptSpan.SetAttribute("attempt", count)
+	if err = c.tracer.Inject(attemptCtx, request.rawRequest.Header); err != nil {
+		c.logger.Error("c.tracer.Inject", c.errField(err))
+		c.endSpan(attemptSpan, nil, err)
+		closeBody(request.rawRequest.Body)
+		return nil, false, err
+	}
+	// sign every attempt with a fresh timestamp, after the rate limit wait and the trace headers.
+	if err = signRequest(c, request); err != nil {
+		c.endSpan(attemptSpan, nil, err)
+		closeBody(request.rawRequest.Body)
+		return nil, false, err
+	}
+	request.prof = profiling{requestStart: time.Now().In(time.UTC)}
+	request.remoteAddress = ""
 
-		var isRetry bool
-		for _, hook := range c.retryHooks {
-			if hook(resp, err) {
-				isRetry = true
-				break
-			}
-		}
+	rawResponse, fallback, err := c.execute(cb, request)
+	request.attempts = count
 
-		if isRetry && count <= c.retry && c.stg != nil {
-			<-time.After(c.stg.backoff(count))
-			continue
+	resp = new(Response)
+	resp.request = request
+	resp.rawResponse = rawResponse
+	resp.hedgedAttempt = request.hedgeWinner
+	if rawResponse != nil {
+		if readErr := c.readBody(resp); readErr != nil {
+			c.endSpan(attemptSpan, nil, readErr)
+			return nil, false, readErr
 		}
+	}
+	request.prof.requestDone = time.Now().In(time.UTC)
+	request.prof.requestCost = request.prof.requestDone.Sub(request.prof.requestStart)
+	request.timings = append(request.timings, request.prof.timing(count, request.remoteAddress))
 
-		break
+	traceTimings(attemptSpan, &request.prof)
+	c.endSpan(attemptSpan, resp, err)
+	return resp, fallback && cb.IsOpen(), err
+}
+
+func (c *Cast) getCircuit(request *Request) *circuit.Circuit {
+	if len(request.circuitName) > 0 {
+		return c.h.GetCircuit(request.circuitName)
 	}
+	return c.h.GetCircuit(c.defaultCircuitName)
+}
+
+// execute sends the request through its circuit, fallback reports a failure counted by the circuit.
+func (c *Cast) execute(cb *circuit.Circuit, request *Request) (rawResponse *http.Response, fallback bool, err error) {
+	client := c.client
+	if request.stream {
+		streamClient := *c.client
+		streamClient.Timeout = 0
+		client = &streamClient
+	}
+	if cb == nil {
+		rawResponse, err = c.send(client, request)
+		return rawResponse, false, err
+	}
+	err = cb.Execute(context.TODO(), func(i context.Context) error {
+		rawResponse, err = c.send(client, request)
+		if err != nil {
+			fallback = true
+			return err
+		}
+		return nil
+	}, func(i context.Context, e error) error {
+		return e
+	})
+	return rawResponse, fallback, err
+}
 
+// readBody reads the body of the response, a stream is left to the caller.
+func (c *Cast) readBody(resp *Response) error {
+	rawResponse := resp.rawResponse
+	wire := decompressResponse(rawResponse)
+	resp.statusCode = rawResponse.StatusCode
+	if resp.request.stream {
+		resp.stream = rawResponse.Body
+		return nil
+	}
+	body, err := ioutil.ReadAll(rawResponse.Body)
 	if err != nil {
-		c.logger.WithError(err).Error("c.client.Do")
-		return nil, err
+		c.logger.Error("ioutil.ReadAll(rawResponse.Body)", c.errField(err))
+		return err
+	}
+	if err := rawResponse.Body.Close(); err != nil {
+		c.logger.Error("rawResponse.Body.Close()", c.errField(err))
+		return err
+	}
+	if wire != nil {
+		resp.compressedSize = wire.n
+		rawResponse.ContentLength = int64(len(body))
 	}
+	resp.body = body
+	request := resp.request
+	request.prof.receivingDone = time.Now().In(time.UTC)
+	if !request.prof.receivingSart.IsZero() {
+		request.prof.receivingCost = request.prof.receivingDone.Sub(request.prof.receivingSart)
+	}
+	return nil
+}
 
-	return resp, nil
+// nextDelay returns the delay before the next attempt, or false if the request is not retried.
+func (c *Cast) nextDelay(request *Request, resp *Response, err error,
+	count int, previous time.Duration, start time.Time) (time.Duration, bool) {
+	if !c.shouldRetry(request, resp, err) {
+		return 0, false
+	}
+	delay, ok := c.retryDelay(&BackoffAttempt{
+		Number:        count,
+		PreviousDelay: previous,
+		Response:      resp,
+		Err:           err,
+	})
+	if !ok || c.maxElapsedTime > 0 && time.Since(start)+delay > c.maxElapsedTime {
+		return 0, false
+	}
+	if c.retryBudget != nil && !c.retryBudget.withdraw() {
+		c.logger.Warn("cast: retry budget exhausted", c.logFields(request)...)
+		return 0, false
+	}
+	return delay, true
+}
+
+// backoff releases the response of the failed attempt and waits for delay, unless the request is done first.
+func (c *Cast) backoff(request *Request, resp *Response, delay time.Duration) error {
+	if c.metrics != nil {
+		c.metrics.ObserveRetry(request.labels())
+	}
+	if err := resp.Close(); err != nil {
+		c.logger.Error("resp.Close()", c.errField(err))
+	}
+	ctx := request.rawRequest.Context()
+	select {
+	case <-ctx.Done():
+		c.logger.Error("backoff", append(c.logFields(request), c.errField(ctx.Err()))...)
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+func closeBody(body io.Reader) {
+	if rc, ok := body.(io.Closer); ok {
+		_ = rc.Close()
+	}
+}
+
+// cancelOnClose cancels the context of a request once its body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnClose) Close() error {
+	err := body.ReadCloser.Close()
+	body.cancel()
+	return err
+}
+
+func (c *Cast) send(client *http.Client, request *Request) (*http.Response, error) {
+	if request.hedgeable() {
+		return c.hedge(client, request)
+	}
+	return client.Do(request.rawRequest)
+}
+
+// logFields returns the fields identifying a request in the logs.
+func (c *Cast) logFields(request *Request) []Field {
+	circuitName := request.circuitName
+	if len(circuitName) == 0 {
+		circuitName = c.defaultCircuitName
+	}
+	return []Field{
+		field("method", request.method),
+		field("url", c.redactor.url(request.rawRequest.URL)),
+		field("attempt", request.attempts),
+		field("duration", request.elapsed()),
+		field("circuit", circuitName),
+	}
 }
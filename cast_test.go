@@ -1,7 +1,11 @@
 package cast
 
 import (
+	"context"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -22,3 +26,26 @@ func ok(tb testing.TB, err error) {
 		tb.FailNow()
 	}
 }
+
+func TestCast_DoStream(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher := w.(http.Flusher)
+		for i := 0; i < 3; i++ {
+			_, _ = fmt.Fprintf(w, "chunk%d\n", i)
+			flusher.Flush()
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/").WithStreamResponse())
+	ok(t, err)
+	defer resp.Close()
+
+	assert(t, resp.IsStream(), "expected a stream")
+	assert(t, resp.Body() == nil, "unexpected buffered body")
+	data, err := ioutil.ReadAll(resp.Stream())
+	ok(t, err)
+	assert(t, string(data) == "chunk0\nchunk1\nchunk2\n", "unexpected stream: %s", data)
+}
@@ -0,0 +1,307 @@
+// Package casttest provides a programmable mock transport to unit test code using cast without sockets.
+package casttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xiaojiaoyu100/cast"
+)
+
+// BaseURL is a base url to use with a mock transport, it never resolves.
+const BaseURL = "http://casttest"
+
+// Transport is an http.RoundTripper serving the registered expectations.
+// A request matching no expectation fails with an error.
+type Transport struct {
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// NewTransport returns an empty mock transport.
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// Setter installs the transport on a cast.
+func (t *Transport) Setter() cast.Setter {
+	return cast.WithRoundTripper(t)
+}
+
+// On registers an expectation for method and path, path segments like {id} match any segment.
+// Expectations are matched in registration order.
+func (t *Transport) On(method, path string) *Expectation {
+	e := &Expectation{
+		method: method,
+		path:   splitPath(path),
+		query:  make(url.Values),
+		times:  -1,
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.expectations = append(t.expectations, e)
+	return e
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.mu.Lock()
+	var matched *Expectation
+	for _, e := range t.expectations {
+		if e.matches(req) {
+			matched = e
+			break
+		}
+	}
+	t.mu.Unlock()
+	if matched == nil {
+		return nil, fmt.Errorf("casttest: no expectation for %s %s", req.Method, req.URL)
+	}
+
+	r := matched.record(req, body)
+	if r.delay > 0 {
+		timer := time.NewTimer(r.delay)
+		defer timer.Stop()
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	header := r.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.statusCode, http.StatusText(r.statusCode)),
+		StatusCode:    r.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(r.body)),
+		ContentLength: int64(len(r.body)),
+		Request:       req,
+	}, nil
+}
+
+// AssertExpectations fails the test if an expectation was never called,
+// or called a different number of times than set by Times.
+func (t *Transport) AssertExpectations(tb testing.TB) {
+	tb.Helper()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, e := range t.expectations {
+		calls := e.Calls()
+		switch {
+		case e.times >= 0 && calls != e.times:
+			tb.Errorf("casttest: %s expected %d calls, got %d", e, e.times, calls)
+		case e.times < 0 && calls == 0:
+			tb.Errorf("casttest: %s was never called", e)
+		}
+	}
+}
+
+// Request is a request received by an expectation.
+type Request struct {
+	Method string
+	URL    *url.URL
+	Header http.Header
+	Body   []byte
+}
+
+type reply struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+	err        error
+	delay      time.Duration
+}
+
+// Expectation is a registered request and its replies.
+// Replies are served in order, the last one being repeated.
+type Expectation struct {
+	method  string
+	path    []string
+	query   url.Values
+	headers http.Header
+	times   int
+
+	mu       sync.Mutex
+	replies  []*reply
+	requests []*Request
+}
+
+// WithQuery restricts the expectation to requests carrying the query parameter.
+func (e *Expectation) WithQuery(key, value string) *Expectation {
+	e.query.Add(key, value)
+	return e
+}
+
+// WithHeader restricts the expectation to requests carrying the header.
+func (e *Expectation) WithHeader(key, value string) *Expectation {
+	if e.headers == nil {
+		e.headers = make(http.Header)
+	}
+	e.headers.Add(key, value)
+	return e
+}
+
+// Times sets the exact number of calls checked by AssertExpectations.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// Reply adds a reply with a status code and a body.
+func (e *Expectation) Reply(statusCode int, body string, header ...string) *Expectation {
+	h := make(http.Header)
+	for i := 0; i+1 < len(header); i += 2 {
+		h.Add(header[i], header[i+1])
+	}
+	return e.add(&reply{statusCode: statusCode, header: h, body: []byte(body)})
+}
+
+// ReplyJSON adds a reply with a status code and v encoded as json.
+func (e *Expectation) ReplyJSON(statusCode int, v interface{}) *Expectation {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return e.add(&reply{err: err})
+	}
+	return e.Reply(statusCode, string(body), "Content-Type", "application/json")
+}
+
+// ReplyError adds a reply failing with err, like a network error.
+func (e *Expectation) ReplyError(err error) *Expectation {
+	return e.add(&reply{err: err})
+}
+
+// Delay delays the last added reply, the request context is honored.
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.replies) > 0 {
+		e.replies[len(e.replies)-1].delay = d
+	}
+	return e
+}
+
+// Calls returns the number of requests received.
+func (e *Expectation) Calls() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.requests)
+}
+
+// Requests returns the requests received, bodies included.
+func (e *Expectation) Requests() []*Request {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*Request(nil), e.requests...)
+}
+
+func (e *Expectation) String() string {
+	s := e.method + " /" + strings.Join(e.path, "/")
+	if len(e.query) > 0 {
+		s += "?" + e.query.Encode()
+	}
+	return s
+}
+
+func (e *Expectation) add(r *reply) *Expectation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.replies = append(e.replies, r)
+	return e
+}
+
+func (e *Expectation) record(req *http.Request, body []byte) *reply {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	n := len(e.requests)
+	e.requests = append(e.requests, &Request{
+		Method: req.Method,
+		URL:    req.URL,
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	switch {
+	case len(e.replies) == 0:
+		return &reply{statusCode: http.StatusOK}
+	case n >= len(e.replies):
+		return e.replies[len(e.replies)-1]
+	}
+	return e.replies[n]
+}
+
+func (e *Expectation) matches(req *http.Request) bool {
+	if !strings.EqualFold(e.method, req.Method) {
+		return false
+	}
+	path := splitPath(req.URL.Path)
+	if len(path) != len(e.path) {
+		return false
+	}
+	for i, segment := range e.path {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			continue
+		}
+		if segment != path[i] {
+			return false
+		}
+	}
+	query := req.URL.Query()
+	for k, vv := range e.query {
+		for _, v := range vv {
+			if !contains(query[k], v) {
+				return false
+			}
+		}
+	}
+	for k, vv := range e.headers {
+		for _, v := range vv {
+			if !contains(req.Header.Values(k), v) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if len(path) == 0 {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func contains(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+	return false
+}
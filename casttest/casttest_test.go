@@ -0,0 +1,101 @@
+package casttest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cep21/circuit/v3"
+	"github.com/xiaojiaoyu100/cast"
+)
+
+func TestTransport_Reply(t *testing.T) {
+	mock := NewTransport()
+	users := mock.On(http.MethodPost, "/users/{id}").WithQuery("expand", "true").
+		ReplyJSON(http.StatusCreated, map[string]string{"name": "cast"}).Times(1)
+
+	c, err := cast.New(cast.WithBaseURL(BaseURL), mock.Setter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := c.NewRequest().Post().WithPath("/users/{id}?expand=true").
+		WithPathParam(map[string]interface{}{"id": 1}).
+		WithJSONBody(map[string]string{"name": "cast"})
+	resp, err := c.Do(context.TODO(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode() != http.StatusCreated || resp.String() != `{"name":"cast"}` {
+		t.Fatalf("unexpected response %d %s", resp.StatusCode(), resp.String())
+	}
+	if body := string(users.Requests()[0].Body); body != `{"name":"cast"}` {
+		t.Fatalf("unexpected request body %s", body)
+	}
+	mock.AssertExpectations(t)
+
+	if _, err := c.Do(context.TODO(), c.NewRequest().WithPath("/unknown")); err == nil {
+		t.Fatal("expected an error for an unexpected request")
+	}
+}
+
+func TestTransport_Retry(t *testing.T) {
+	mock := NewTransport()
+	e := mock.On(http.MethodGet, "/").
+		ReplyError(io.EOF).
+		Reply(http.StatusServiceUnavailable, "").
+		Reply(http.StatusOK, "ok").Times(3)
+
+	c, err := cast.New(cast.WithBaseURL(BaseURL), mock.Setter(), cast.WithRetry(2),
+		cast.WithRetryStatusCodes(http.StatusServiceUnavailable), cast.WithConstantBackoffStrategy(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.String() != "ok" || e.Calls() != 3 {
+		t.Fatalf("unexpected response %s after %d calls", resp.String(), e.Calls())
+	}
+	mock.AssertExpectations(t)
+}
+
+func TestTransport_Delay(t *testing.T) {
+	mock := NewTransport()
+	mock.On(http.MethodGet, "/").Reply(http.StatusOK, "").Delay(time.Second)
+
+	c, err := cast.New(cast.WithBaseURL(BaseURL), mock.Setter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/").WithTimeout(10*time.Millisecond))
+	if err == nil || time.Since(start) >= time.Second {
+		t.Fatalf("expected the delay to be cut by the request timeout, got %v", err)
+	}
+}
+
+func TestTransport_OpenCircuit(t *testing.T) {
+	mock := NewTransport()
+	e := mock.On(http.MethodGet, "/").ReplyError(errors.New("connection refused"))
+
+	c, err := cast.New(cast.WithBaseURL(BaseURL), mock.Setter(), cast.AddCircuitConfig("partner"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cerr circuit.Error
+	for i := 0; i < 20 && !errors.As(err, &cerr); i++ {
+		_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/").WithCircuit("partner"))
+	}
+	if !errors.As(err, &cerr) || !cerr.CircuitOpen() {
+		t.Fatalf("expected an open circuit, got %v", err)
+	}
+	calls := e.Calls()
+	_, _ = c.Do(context.TODO(), c.NewRequest().WithPath("/").WithCircuit("partner"))
+	if e.Calls() != calls {
+		t.Fatal("expected the open circuit to short circuit the transport")
+	}
+}
@@ -0,0 +1,33 @@
+package casttest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xiaojiaoyu100/cast"
+	"github.com/xiaojiaoyu100/cast/casttest"
+)
+
+func ExampleTransport() {
+	mock := casttest.NewTransport()
+	users := mock.On(http.MethodGet, "/users/{id}").
+		Reply(http.StatusServiceUnavailable, "").
+		ReplyJSON(http.StatusOK, map[string]string{"name": "cast"})
+	c, err := cast.New(cast.WithBaseURL(casttest.BaseURL), mock.Setter(), cast.WithDump(),
+		cast.WithRetry(1), cast.WithRetryStatusCodes(http.StatusServiceUnavailable),
+		cast.WithConstantBackoffStrategy(time.Millisecond))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/users/1"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(resp.StatusCode(), resp.String(), users.Calls())
+	// Output: 200 {"name":"cast"} 2
+}
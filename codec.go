@@ -0,0 +1,106 @@
+package cast
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codec encodes request bodies and decodes response bodies of a content type.
+type Codec interface {
+	// ContentType is the media type the codec is registered for, e.g. "application/json".
+	// It is not sent, a request body keeps the Content-Type it was built with.
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return applicationJSON }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type xmlCodec struct{}
+
+func (xmlCodec) ContentType() string { return applicationXML }
+
+func (xmlCodec) Marshal(v interface{}) ([]byte, error) { return xml.Marshal(v) }
+
+func (xmlCodec) Unmarshal(data []byte, v interface{}) error { return xml.Unmarshal(data, v) }
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string { return applicationYAML }
+
+func (yamlCodec) Marshal(v interface{}) ([]byte, error) { return yaml.Marshal(v) }
+
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+
+// Built-in codecs, registered by default with ProtobufCodec.
+var (
+	JSONCodec Codec = jsonCodec{}
+	XMLCodec  Codec = xmlCodec{}
+	YAMLCodec Codec = yamlCodec{}
+)
+
+// codecRegistry maps media types, without parameters, to codecs.
+type codecRegistry map[string]Codec
+
+var defaultCodecs = codecRegistry{
+	"application/json":   JSONCodec,
+	"application/xml":    XMLCodec,
+	"text/xml":           XMLCodec,
+	"application/yaml":   YAMLCodec,
+	"application/x-yaml": YAMLCodec,
+	"text/yaml":          YAMLCodec,
+
+	"application/x-protobuf": ProtobufCodec,
+	"application/protobuf":   ProtobufCodec,
+}
+
+func (r codecRegistry) clone() codecRegistry {
+	codecs := make(codecRegistry, len(r))
+	for k, v := range r {
+		codecs[k] = v
+	}
+	return codecs
+}
+
+func (r codecRegistry) register(codec Codec, contentTypes ...string) error {
+	for _, ct := range append([]string{codec.ContentType()}, contentTypes...) {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			return err
+		}
+		r[mediaType] = codec
+	}
+	return nil
+}
+
+// lookup returns the codec of a content type, JSON if it is empty.
+// Structured syntax suffixes fall back to their base type, e.g. application/problem+json to application/json.
+func (r codecRegistry) lookup(ct string) (Codec, error) {
+	if len(ct) == 0 {
+		ct = applicationJSON
+	}
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return nil, err
+	}
+	if codec, ok := r[mediaType]; ok {
+		return codec, nil
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i >= 0 {
+		if codec, ok := r["application/"+mediaType[i+1:]]; ok {
+			return codec, nil
+		}
+	}
+	return nil, fmt.Errorf("cast: no codec for content type %q", mediaType)
+}
@@ -0,0 +1,86 @@
+package cast
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// upperCodec encodes strings in upper case, to tell it apart from the built-in codecs.
+type upperCodec struct{}
+
+func (upperCodec) ContentType() string { return "text/x-upper" }
+
+func (upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(v.(string))), nil
+}
+
+func (upperCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*string) = strings.ToLower(string(data))
+	return nil
+}
+
+type countingJSONCodec struct {
+	jsonCodec
+	marshaled int
+}
+
+func (c *countingJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	c.marshaled++
+	return json.Marshal(v)
+}
+
+func TestCodec(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	jsonCodec := &countingJSONCodec{}
+	c, err := New(WithBaseURL(ts.URL), WithCodec(upperCodec{}), WithCodec(jsonCodec))
+	ok(t, err)
+
+	resp, err := c.Do(context.TODO(), c.NewRequest().Post().WithBody("text/x-upper", "cast"))
+	ok(t, err)
+	assert(t, resp.String() == "CAST", "unexpected body %s", resp.String())
+	var s string
+	ok(t, resp.Decode(&s))
+	assert(t, s == "cast", "unexpected decoded body %s", s)
+
+	user, _, err := Call[callUser](context.TODO(), c, c.NewRequest().Post().WithJSONBody(callUser{Name: "cast"}))
+	ok(t, err)
+	assert(t, user.Name == "cast", "unexpected user %+v", user)
+	assert(t, jsonCodec.marshaled > 0, "expected the registered JSON codec to be used")
+
+	resp, err = c.Do(context.TODO(), c.NewRequest().Post().WithBody("application/yaml", callUser{Name: "cast"}))
+	ok(t, err)
+	var m map[string]string
+	ok(t, resp.Decode(&m))
+	assert(t, m["name"] == "cast", "unexpected decoded body %v", m)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().Post().WithBody("application/msgpack", callUser{}))
+	assert(t, err != nil, "expected an error for an unregistered content type")
+}
+
+func TestCodecRegistry_Lookup(t *testing.T) {
+	tests := [...]struct {
+		contentType string
+		want        Codec
+	}{
+		0: {contentType: "", want: JSONCodec},
+		1: {contentType: "application/problem+json", want: JSONCodec},
+		2: {contentType: "application/atom+xml; charset=utf-8", want: XMLCodec},
+		3: {contentType: "text/yaml", want: YAMLCodec},
+		4: {contentType: "application/octet-stream", want: nil},
+	}
+	for i, tt := range tests {
+		codec, _ := defaultCodecs.lookup(tt.contentType)
+		assert(t, codec == tt.want, "%d unexpected codec %T", i, codec)
+	}
+}
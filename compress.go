@@ -0,0 +1,203 @@
+package cast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings supported by WithCompressedBody and WithAcceptEncoding.
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingBrotli  = "br"
+	EncodingZstd    = "zstd"
+)
+
+const (
+	contentEncoding = "Content-Encoding"
+	acceptEncoding  = "Accept-Encoding"
+	contentLength   = "Content-Length"
+)
+
+func checkEncoding(encoding string) error {
+	switch encoding {
+	case EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd:
+		return nil
+	}
+	return fmt.Errorf("cast: unsupported content encoding %q", encoding)
+}
+
+func compressWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	case EncodingDeflate:
+		return zlib.NewWriter(w), nil
+	case EncodingBrotli:
+		return brotli.NewWriter(w), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w)
+	}
+	return nil, checkEncoding(encoding)
+}
+
+func decompressReader(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	case EncodingDeflate:
+		return zlib.NewReader(r)
+	case EncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case EncodingZstd:
+		d, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+	return nil, checkEncoding(encoding)
+}
+
+func compress(encoding string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := compressWriter(encoding, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compressStream compresses rc on the fly.
+func compressStream(encoding string, rc io.ReadCloser) (io.ReadCloser, error) {
+	if err := checkEncoding(encoding); err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer rc.Close()
+		w, err := compressWriter(encoding, pw)
+		if err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		if _, err := io.Copy(w, rc); err != nil {
+			_ = pw.CloseWithError(err)
+			return
+		}
+		_ = pw.CloseWithError(w.Close())
+	}()
+	return pr, nil
+}
+
+// contentEncodings returns the encodings of a Content-Encoding header in the order they were applied,
+// ok is false if one of them is unsupported.
+func contentEncodings(header string) (encodings []string, ok bool) {
+	for _, e := range strings.Split(header, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if len(e) == 0 || e == "identity" {
+			continue
+		}
+		if checkEncoding(e) != nil {
+			return nil, false
+		}
+		encodings = append(encodings, e)
+	}
+	return encodings, true
+}
+
+// decompressBody undoes the encodings of a Content-Encoding header, last applied first.
+func decompressBody(encodings []string, body io.ReadCloser) io.ReadCloser {
+	var (
+		r       io.Reader = body
+		closers           = []io.Closer{body}
+	)
+	for i := len(encodings) - 1; i >= 0; i-- {
+		d := &lazyDecompressor{encoding: encodings[i], src: r}
+		closers = append([]io.Closer{d}, closers...)
+		r = d
+	}
+	return &multiCloser{Reader: r, closers: closers}
+}
+
+// decompressResponse decodes the body of a compressed response on the fly and drops its
+// Content-Encoding, as the transport does for gzip.
+// It returns a reader counting the compressed bytes, nil if the response is not compressed.
+func decompressResponse(rawResponse *http.Response) *countingReader {
+	encodings, ok := contentEncodings(rawResponse.Header.Get(contentEncoding))
+	if !ok || len(encodings) == 0 {
+		return nil
+	}
+	wire := &countingReader{ReadCloser: rawResponse.Body}
+	rawResponse.Body = decompressBody(encodings, wire)
+	rawResponse.Header.Del(contentEncoding)
+	rawResponse.Header.Del(contentLength)
+	rawResponse.ContentLength = -1
+	rawResponse.Uncompressed = true
+	return wire
+}
+
+// lazyDecompressor starts decompressing on the first read, so that an empty body reads as empty.
+type lazyDecompressor struct {
+	encoding string
+	src      io.Reader
+	r        io.ReadCloser
+	err      error
+}
+
+func (d *lazyDecompressor) Read(p []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.r, d.err = decompressReader(d.encoding, d.src)
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(p)
+}
+
+func (d *lazyDecompressor) Close() error {
+	if d.r == nil {
+		return nil
+	}
+	return d.r.Close()
+}
+
+type countingReader struct {
+	io.ReadCloser
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n += n
+	return n, err
+}
+
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m *multiCloser) Close() error {
+	var err error
+	for _, c := range m.closers {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package cast
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	payload := strings.Repeat("cast", 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+		if encoding := r.Header.Get("Content-Encoding"); len(encoding) > 0 {
+			rc, err := decompressReader(encoding, r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = rc
+		}
+		data, _ := io.ReadAll(body)
+		if string(data) != payload {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		encoding := strings.Split(r.Header.Get("Accept-Encoding"), ",")[0]
+		compressed, err := compress(encoding, data)
+		if err != nil {
+			w.WriteHeader(http.StatusNotAcceptable)
+			return
+		}
+		w.Header().Set("Content-Encoding", encoding)
+		_, _ = w.Write(compressed)
+	}))
+	defer ts.Close()
+
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingBrotli, EncodingZstd} {
+		logger := &captureLogger{}
+		c, err := New(WithBaseURL(ts.URL), WithLogger(logger), WithAcceptEncoding(encoding, EncodingGzip))
+		ok(t, err)
+
+		resp, err := c.Do(context.TODO(), c.NewRequest().Post().WithPlainBody(payload).WithCompressedBody(encoding))
+		ok(t, err)
+		assert(t, resp.StatusOk(), "%s: unexpected status code %d", encoding, resp.StatusCode())
+		assert(t, resp.String() == payload, "%s: unexpected body %s", encoding, resp.String())
+		assert(t, len(resp.Header().Get("Content-Encoding")) == 0, "%s: unexpected Content-Encoding", encoding)
+
+		fields := logger.entries[0].fields
+		assert(t, fields["response_size"] == len(payload), "%s: unexpected response size %v", encoding, fields["response_size"])
+		size := fields["response_compressed_size"].(int)
+		assert(t, size < len(payload), "%s: unexpected response compressed size %d", encoding, size)
+		size = fields["request_compressed_size"].(int)
+		assert(t, size < len(payload), "%s: unexpected request compressed size %d", encoding, size)
+	}
+
+	_, err := New(WithAcceptEncoding("lz4"))
+	assert(t, err != nil, "expected an error for an unsupported encoding")
+}
+
+func TestCompression_StreamBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc, err := decompressReader(r.Header.Get("Content-Encoding"), r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = io.Copy(w, rc)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().Post().
+		WithReaderBody("text/plain", strings.NewReader("cast")).WithCompressedBody(EncodingZstd))
+	ok(t, err)
+	assert(t, resp.String() == "cast", "unexpected body %s", resp.String())
+}
@@ -13,6 +13,10 @@ func (err Error) Error() string {
 	return string(err)
 }
 
+// ErrBodyNotRewindable is returned when a streamed request body has to be sent again,
+// e.g. on retry, but its reader can't be reopened or seeked.
+const ErrBodyNotRewindable Error = "cast: request body is not rewindable"
+
 func isNetworkErr(err error) bool {
 	netErr, ok := err.(net.Error)
 	return ok && (netErr.Temporary() || netErr.Timeout())
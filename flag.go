@@ -1,9 +1,32 @@
 package cast
 
+// DumpFlag selects what the dump hook logs.
+type DumpFlag int
+
 const (
-	fHeader int = 1 << iota
-	fParam
-	fResponse
-	fTiming
-	fStd = fHeader | fParam | fResponse | fTiming
+	// DumpHeader logs the remote address, protocol and headers.
+	DumpHeader DumpFlag = 1 << iota
+	// DumpParam logs the request body.
+	DumpParam
+	// DumpResponse logs the response body.
+	DumpResponse
+	// DumpTiming logs the timings of the last attempt.
+	DumpTiming
+	// DumpOnError only logs the responses whose status code is not 2xx, alone it means DumpStd|DumpOnError.
+	DumpOnError
+	// DumpStd logs everything.
+	DumpStd = DumpHeader | DumpParam | DumpResponse | DumpTiming
+	// DumpNone disables the dump.
+	DumpNone DumpFlag = 0
 )
+
+func dumpFlags(flags []DumpFlag) DumpFlag {
+	var f DumpFlag
+	for _, flag := range flags {
+		f |= flag
+	}
+	if f == DumpOnError {
+		f |= DumpStd
+	}
+	return f
+}
@@ -1,13 +1,21 @@
 module github.com/xiaojiaoyu100/cast
 
-go 1.13
+go 1.22
 
 require (
+	github.com/andybalholm/brotli v1.1.1
 	github.com/cep21/circuit/v3 v3.1.0
 	github.com/google/go-querystring v1.0.0
 	github.com/jtacoma/uritemplates v1.0.0
-	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/klauspost/compress v1.18.0
 	github.com/opentracing/opentracing-go v1.2.0
 	github.com/sirupsen/logrus v1.4.2
+	golang.org/x/time v0.3.0
+	google.golang.org/protobuf v1.31.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
 	golang.org/x/sys v0.0.0-20191029155521-f43be2a4598c // indirect
 )
@@ -6,6 +6,9 @@ const (
 	formURLEncoded  = "application/x-www-form-urlencoded"
 	applicationXML  = "application/xml; charset=utf-8"
 	textPlain       = "text/plain; charset=utf-8"
+	applicationYAML = "application/yaml"
+
+	applicationProtobuf = "application/x-protobuf"
 )
 
 const (
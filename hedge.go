@@ -0,0 +1,125 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+type hedgeResult struct {
+	attempt       int
+	rawResponse   *http.Response
+	err           error
+	prof          profiling
+	remoteAddress string
+	cancel        context.CancelFunc
+}
+
+func (r *Request) hedgeable() bool {
+	return r.hedgeDelay > 0 && r.hedgeMaxExtra > 0 && (r.idempotent || idempotentMethods[r.rawRequest.Method]) &&
+		r.concurrentBody()
+}
+
+// concurrentBody reports whether the body can be sent by parallel attempts.
+// A stream body shares its reader between attempts, unless it is reopened by WithReaderBodyFunc.
+func (r *Request) concurrentBody() bool {
+	switch body := r.body.(type) {
+	case *requestReaderBody:
+		return body.getBody != nil
+	case streamRequestBody:
+		return false
+	}
+	return true
+}
+
+// hedge sends the request and fires another attempt every hedgeDelay until a response arrives
+// or hedgeMaxExtra extra attempts are in flight. The first response wins and the others are cancelled.
+func (c *Cast) hedge(client *http.Client, request *Request) (*http.Response, error) {
+	parent := request.untracedCtx
+	if parent == nil {
+		parent = request.rawRequest.Context()
+	}
+	results := make(chan *hedgeResult, request.hedgeMaxExtra+1)
+	cancels := make([]context.CancelFunc, 0, request.hedgeMaxExtra+1)
+	launch := func(attempt int) bool {
+		raw := request.rawRequest
+		if attempt > 0 && !c.allowHedge(request) {
+			return false
+		}
+		ctx, cancel := context.WithCancel(parent)
+		res := &hedgeResult{attempt: attempt, cancel: cancel}
+		r := raw.Clone(httptrace.WithClientTrace(ctx, newClientTrace(&res.prof, &res.remoteAddress)))
+		if attempt > 0 && raw.Body != nil && raw.Body != http.NoBody {
+			if raw.GetBody == nil {
+				cancel()
+				return false
+			}
+			body, err := raw.GetBody()
+			if err != nil {
+				cancel()
+				return false
+			}
+			r.Body = body
+		}
+		cancels = append(cancels, cancel)
+		go func() {
+			res.rawResponse, res.err = client.Do(r)
+			results <- res
+		}()
+		return true
+	}
+
+	launch(0)
+	launched, pending := 1, 1
+	timer := time.NewTimer(request.hedgeDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case res := <-results:
+			pending--
+			// a failed attempt is left to the retry policy, hedges only race a slow one.
+			if res.err != nil && pending > 0 {
+				continue
+			}
+			for i, cancel := range cancels {
+				if i != res.attempt || res.err != nil {
+					cancel()
+				}
+			}
+			go drainHedges(results, pending)
+			if res.err != nil {
+				return nil, res.err
+			}
+			requestStart := request.prof.requestStart
+			request.prof = res.prof
+			request.prof.requestStart = requestStart
+			request.remoteAddress = res.remoteAddress
+			request.hedgeWinner = res.attempt
+			res.rawResponse.Body = &cancelOnClose{ReadCloser: res.rawResponse.Body, cancel: res.cancel}
+			return res.rawResponse, nil
+		case <-timer.C:
+			if launched <= request.hedgeMaxExtra && launch(launched) {
+				launched++
+				pending++
+				timer.Reset(request.hedgeDelay)
+			}
+		}
+	}
+}
+
+// drainHedges releases the responses of the cancelled attempts.
+func drainHedges(results chan *hedgeResult, pending int) {
+	for i := 0; i < pending; i++ {
+		res := <-results
+		if res.rawResponse != nil {
+			_ = res.rawResponse.Body.Close()
+		}
+	}
+}
+
+// allowHedge doesn't wait for the rate limit, an extra attempt is only fired if a token is available.
+func (c *Cast) allowHedge(request *Request) bool {
+	_, l := c.limiter(request)
+	return l == nil || l.Allow()
+}
@@ -0,0 +1,119 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCast_Hedging(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			_, _ = w.Write([]byte("slow"))
+			return
+		}
+		_, _ = w.Write([]byte("fast"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+
+	start := time.Now()
+	resp, err := c.Do(context.TODO(), c.NewRequest().Get().WithHedging(20*time.Millisecond, 1))
+	ok(t, err)
+	assert(t, resp.String() == "fast", "unexpected body %s", resp.String())
+	assert(t, resp.HedgedAttempt() == 1, "unexpected hedged attempt %d", resp.HedgedAttempt())
+	assert(t, time.Since(start) < 500*time.Millisecond, "expected the hedged attempt to win")
+}
+
+func TestCast_HedgingNonIdempotent(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest().Post().WithHedging(time.Millisecond, 2))
+	ok(t, err)
+	assert(t, atomic.LoadInt32(&hits) == 1, "unexpected hits %d", hits)
+}
+
+func TestCast_HedgeMaxElapsedTime(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithMaxElapsedTime(100*time.Millisecond))
+	ok(t, err)
+
+	start := time.Now()
+	_, err = c.Do(context.TODO(), c.NewRequest().WithHedging(20*time.Millisecond, 1))
+	assert(t, errors.Is(err, context.DeadlineExceeded), "unexpected error %v", err)
+	assert(t, time.Since(start) < 500*time.Millisecond, "expected the hedged attempts to be aborted")
+}
+
+func TestCast_HedgingEarlyError(t *testing.T) {
+	var hits int32
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&hits, 1)
+		return nil, errors.New("connection refused")
+	})
+	c, err := New(WithBaseURL("http://example.com"), WithRoundTripper(rt))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithHedging(50*time.Millisecond, 2))
+	assert(t, err != nil, "expected an error")
+	time.Sleep(100 * time.Millisecond)
+	assert(t, atomic.LoadInt32(&hits) == 1, "unexpected hits %d", hits)
+}
+
+func TestCast_HedgingStreamBody(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		b, _ := ioutil.ReadAll(r.Body)
+		time.Sleep(50 * time.Millisecond)
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+
+	payload := strings.Repeat("payload", 64)
+	request := c.NewRequest().Post().Idempotent().WithHedging(time.Millisecond, 2).
+		WithReaderBody(textPlain, strings.NewReader(payload))
+	resp, err := c.Do(context.TODO(), request)
+	ok(t, err)
+	assert(t, resp.String() == payload, "unexpected body")
+	assert(t, atomic.LoadInt32(&hits) == 1, "expected a shared reader not to be hedged, got %d hits", hits)
+
+	request = c.NewRequest().Post().Idempotent().WithHedging(time.Millisecond, 2).
+		WithReaderBodyFunc(textPlain, func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(strings.NewReader(payload)), nil
+		})
+	resp, err = c.Do(context.TODO(), request)
+	ok(t, err)
+	assert(t, resp.String() == payload, "unexpected body")
+	assert(t, atomic.LoadInt32(&hits) > 2, "expected a reopened body to be hedged, got %d hits", hits)
+}
@@ -0,0 +1,145 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const maxHTTPErrorBody = 4 << 10
+
+// HTTPError is returned for a non-2xx response when WithErrorOnStatus or Request.ExpectSuccess is set.
+type HTTPError struct {
+	StatusCode int
+	Method     string
+	// URL is redacted like in the logs.
+	URL    string
+	Header http.Header
+	// Body is the response body truncated to 4KB.
+	Body     []byte
+	Attempts int
+	// DecodeErr is the error decoding the body into the value of Request.WithErrorBody, if any.
+	DecodeErr error
+
+	body   []byte
+	codecs codecRegistry
+}
+
+func (c *Cast) newHTTPError(resp *Response) *HTTPError {
+	request := resp.request
+	body := resp.body
+	if resp.stream != nil {
+		body, _ = io.ReadAll(io.LimitReader(resp.stream, maxHTTPErrorBody))
+		_ = resp.Close()
+	}
+	err := &HTTPError{
+		StatusCode: resp.statusCode,
+		Method:     request.method,
+		URL:        c.redactor.url(request.rawRequest.URL),
+		Header:     resp.Header(),
+		Body:       body,
+		Attempts:   request.attempts,
+		body:       body,
+		codecs:     request.codecs,
+	}
+	if len(err.Body) > maxHTTPErrorBody {
+		err.Body = err.Body[:maxHTTPErrorBody]
+	}
+	if request.errorBody != nil {
+		err.DecodeErr = err.Decode(request.errorBody)
+	}
+	return err
+}
+
+func (err *HTTPError) Error() string {
+	msg := fmt.Sprintf("cast: %s %s: %d %s", err.Method, err.URL, err.StatusCode, http.StatusText(err.StatusCode))
+	if len(err.Body) > 0 {
+		msg += ": " + strings.TrimSpace(string(err.Body))
+	}
+	return msg
+}
+
+// Decode decodes the whole error body into v with the codec registered for the response Content-Type,
+// JSON if there is none.
+func (err *HTTPError) Decode(v interface{}) error {
+	if len(err.body) == 0 {
+		return nil
+	}
+	codecs := defaultCodecs
+	if err.codecs != nil {
+		codecs = err.codecs
+	}
+	codec, lookupErr := codecs.lookup(err.Header.Get(contentType))
+	if lookupErr != nil {
+		return lookupErr
+	}
+	return codec.Unmarshal(err.body, v)
+}
+
+func statusCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return 0
+}
+
+// IsBadRequest reports whether err is an *HTTPError with status code 400.
+func IsBadRequest(err error) bool {
+	return statusCode(err) == http.StatusBadRequest
+}
+
+// IsUnauthorized reports whether err is an *HTTPError with status code 401.
+func IsUnauthorized(err error) bool {
+	return statusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden reports whether err is an *HTTPError with status code 403.
+func IsForbidden(err error) bool {
+	return statusCode(err) == http.StatusForbidden
+}
+
+// IsNotFound reports whether err is an *HTTPError with status code 404.
+func IsNotFound(err error) bool {
+	return statusCode(err) == http.StatusNotFound
+}
+
+// IsConflict reports whether err is an *HTTPError with status code 409.
+func IsConflict(err error) bool {
+	return statusCode(err) == http.StatusConflict
+}
+
+// IsTooManyRequests reports whether err is an *HTTPError with status code 429.
+func IsTooManyRequests(err error) bool {
+	return statusCode(err) == http.StatusTooManyRequests
+}
+
+// IsClientError reports whether err is an *HTTPError with a 4xx status code.
+func IsClientError(err error) bool {
+	code := statusCode(err)
+	return code >= 400 && code <= 499
+}
+
+// IsServerError reports whether err is an *HTTPError with a 5xx status code.
+func IsServerError(err error) bool {
+	code := statusCode(err)
+	return code >= 500 && code <= 599
+}
+
+// IsTimeout reports whether err is a timeout: a context deadline, a network timeout,
+// or an *HTTPError with status code 408 or 504.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	code := statusCode(err)
+	return code == http.StatusRequestTimeout || code == http.StatusGatewayTimeout
+}
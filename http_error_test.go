@@ -0,0 +1,71 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"user_not_found"}`))
+		case "/yaml":
+			w.Header().Set("Content-Type", "application/yaml")
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte("code: user_exists\n"))
+		case "/large":
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(strings.Repeat("a", 2*maxHTTPErrorBody)))
+		}
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/missing"))
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusNotFound, "unexpected status code %d", resp.StatusCode())
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/missing?token=1").WithErrorBody(&body))
+	var httpErr *HTTPError
+	assert(t, errors.As(err, &httpErr), "unexpected error %v", err)
+	assert(t, IsNotFound(err) && IsClientError(err) && !IsServerError(err), "unexpected predicates for %v", err)
+	assert(t, httpErr.Method == http.MethodGet && httpErr.Attempts == 1, "unexpected error %+v", httpErr)
+	assert(t, body.Code == "user_not_found", "unexpected error body %+v", body)
+	assert(t, httpErr.DecodeErr == nil, "unexpected decode error %v", httpErr.DecodeErr)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/yaml").WithErrorBody(&body))
+	assert(t, IsConflict(err), "unexpected error %v", err)
+	assert(t, body.Code == "user_exists", "unexpected error body %+v", body)
+	assert(t, httpErr.Header.Get("Content-Type") == "application/json", "unexpected header %v", httpErr.Header)
+
+	c, err = New(WithBaseURL(ts.URL), WithErrorOnStatus())
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/large"))
+	assert(t, errors.As(err, &httpErr), "unexpected error %v", err)
+	assert(t, IsServerError(err) && !IsTimeout(err), "unexpected predicates for %v", err)
+	assert(t, len(httpErr.Body) == maxHTTPErrorBody, "unexpected body length %d", len(httpErr.Body))
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/large").WithErrorBody(&body))
+	assert(t, errors.As(err, &httpErr), "unexpected error %v", err)
+	assert(t, httpErr.DecodeErr != nil, "expected a decode error")
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	ok(t, err)
+}
+
+func TestIsTimeout(t *testing.T) {
+	assert(t, IsTimeout(context.DeadlineExceeded), "expected a timeout")
+	assert(t, IsTimeout(&HTTPError{StatusCode: http.StatusGatewayTimeout}), "expected a timeout")
+	assert(t, !IsTimeout(errors.New("cast")), "unexpected timeout")
+}
@@ -4,11 +4,81 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// Field is a structured log field.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func field(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+func errField(err error) Field {
+	return Field{Key: "error", Value: err}
+}
+
+// errField returns the error field with the url of a *url.Error redacted.
+func (c *Cast) errField(err error) Field {
+	return errField(c.redactor.err(err))
+}
+
+// Logger is the structured logger of cast.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
 // Logger return the underlying log instance.
+// It is the default logrus logger, which is no longer written to once WithLogger replaces it.
 func (c *Cast) Logger() *logrus.Logger {
+	return c.logrus
+}
+
+// StructuredLogger returns the logger cast writes to, see WithLogger.
+func (c *Cast) StructuredLogger() Logger {
 	return c.logger
 }
 
+type logrusLogger struct {
+	logger *logrus.Logger
+}
+
+// NewLogrusLogger adapts a logrus logger.
+func NewLogrusLogger(l *logrus.Logger) Logger {
+	return &logrusLogger{logger: l}
+}
+
+func (l *logrusLogger) entry(fields []Field) *logrus.Entry {
+	data := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		if err, ok := f.Value.(error); ok {
+			data[f.Key] = err.Error()
+			continue
+		}
+		data[f.Key] = f.Value
+	}
+	return l.logger.WithFields(data)
+}
+
+func (l *logrusLogger) Debug(msg string, fields ...Field) {
+	l.entry(fields).Debug(msg)
+}
+
+func (l *logrusLogger) Info(msg string, fields ...Field) {
+	l.entry(fields).Info(msg)
+}
+
+func (l *logrusLogger) Warn(msg string, fields ...Field) {
+	l.entry(fields).Warn(msg)
+}
+
+func (l *logrusLogger) Error(msg string, fields ...Field) {
+	l.entry(fields).Error(msg)
+}
+
 // LogHook log hook模板
 type LogHook func(entry *logrus.Entry)
 
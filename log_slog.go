@@ -0,0 +1,43 @@
+package cast
+
+import (
+	"context"
+	"log/slog"
+)
+
+type slogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger adapts a log/slog logger.
+func NewSlogLogger(l *slog.Logger) Logger {
+	return &slogLogger{logger: l}
+}
+
+func (l *slogLogger) log(level slog.Level, msg string, fields []Field) {
+	ctx := context.Background()
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+func (l *slogLogger) Debug(msg string, fields ...Field) {
+	l.log(slog.LevelDebug, msg, fields)
+}
+
+func (l *slogLogger) Info(msg string, fields ...Field) {
+	l.log(slog.LevelInfo, msg, fields)
+}
+
+func (l *slogLogger) Warn(msg string, fields ...Field) {
+	l.log(slog.LevelWarn, msg, fields)
+}
+
+func (l *slogLogger) Error(msg string, fields ...Field) {
+	l.log(slog.LevelError, msg, fields)
+}
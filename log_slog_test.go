@@ -0,0 +1,31 @@
+package cast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithLogger_Slog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var buffer bytes.Buffer
+	logger := NewSlogLogger(slog.New(slog.NewJSONHandler(&buffer, nil)))
+	c, err := New(WithBaseURL(ts.URL), WithLogger(logger), WithDefaultCircuit("default"))
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/path"))
+	ok(t, err)
+
+	var line map[string]interface{}
+	ok(t, json.Unmarshal(buffer.Bytes(), &line))
+	assert(t, line["method"] == "GET", "unexpected method %v", line["method"])
+	assert(t, line["url"] == ts.URL+"/path", "unexpected url %v", line["url"])
+	assert(t, line["status"] == float64(http.StatusOK), "unexpected status %v", line["status"])
+	assert(t, line["attempt"] == float64(1), "unexpected attempt %v", line["attempt"])
+	assert(t, line["circuit"] == "default", "unexpected circuit %v", line["circuit"])
+}
@@ -0,0 +1,17 @@
+package cast
+
+import (
+	"testing"
+)
+
+func TestCast_Logger(t *testing.T) {
+	c, err := New()
+	ok(t, err)
+	assert(t, c.Logger().ReportCaller, "expected the default logger to report the caller")
+	assert(t, c.StructuredLogger() != nil, "expected a structured logger")
+
+	logger := NewLogrusLogger(c.Logger())
+	c, err = New(WithLogger(logger))
+	ok(t, err)
+	assert(t, c.StructuredLogger() == logger, "unexpected structured logger")
+}
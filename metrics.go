@@ -0,0 +1,98 @@
+package cast
+
+import (
+	"time"
+
+	"github.com/cep21/circuit/v3"
+)
+
+// RequestLabels identifies the requests a metric belongs to.
+type RequestLabels struct {
+	Method string
+	Host   string
+	// Route is the path template given to WithPath, before path params are expanded.
+	Route string
+}
+
+// RequestMetrics describes a finished request.
+type RequestMetrics struct {
+	RequestLabels
+	// StatusCode is zero if no response was received.
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+	Attempts   int
+	FromCache  bool
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	Sending    time.Duration
+	Waiting    time.Duration
+	Receiving  time.Duration
+}
+
+// MetricsRecorder receives the metrics of cast, implementations must be safe for concurrent use.
+type MetricsRecorder interface {
+	// InFlight is called with 1 when a request starts and -1 when it ends.
+	InFlight(labels RequestLabels, delta int)
+	ObserveRequest(metrics *RequestMetrics)
+	ObserveRetry(labels RequestLabels)
+	CircuitStateChanged(name string, open bool)
+}
+
+func (r *Request) labels() RequestLabels {
+	labels := RequestLabels{
+		Method: r.method,
+		Route:  r.pathTemplate,
+	}
+	if r.rawRequest != nil {
+		labels.Host = r.rawRequest.URL.Host
+	}
+	return labels
+}
+
+func (r *Request) metrics(resp *Response, err error, start time.Time) *RequestMetrics {
+	m := &RequestMetrics{
+		RequestLabels: r.labels(),
+		Err:           err,
+		Duration:      time.Since(start),
+		Attempts:      r.attempts,
+		DNS:           r.prof.dnsCost,
+		Connect:       r.prof.connectCost,
+		TLS:           r.prof.tlsHandshakeCost,
+		Sending:       r.prof.sendingCost,
+		Waiting:       r.prof.waitingCost,
+		Receiving:     r.prof.receivingCost,
+	}
+	if resp != nil {
+		m.StatusCode = resp.statusCode
+		m.FromCache = resp.fromCache
+	}
+	return m
+}
+
+// circuitMetrics forwards the circuit transitions to the recorder of cast, if any.
+type circuitMetrics struct {
+	name string
+	cast *Cast
+}
+
+func (m circuitMetrics) Closed(time.Time) {
+	if m.cast.metrics != nil {
+		m.cast.metrics.CircuitStateChanged(m.name, false)
+	}
+}
+
+func (m circuitMetrics) Opened(time.Time) {
+	if m.cast.metrics != nil {
+		m.cast.metrics.CircuitStateChanged(m.name, true)
+	}
+}
+
+func (c *Cast) circuitMetricsConfig(name string) circuit.Config {
+	return circuit.Config{
+		Metrics: circuit.MetricsCollectors{
+			Circuit: []circuit.Metrics{circuitMetrics{name: name, cast: c}},
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package cast
+
+import (
+	"sync"
+	"testing"
+)
+
+type fakeMetricsRecorder struct {
+	mu       sync.Mutex
+	requests []*RequestMetrics
+	retries  int
+	inFlight int
+	circuits map[string]bool
+}
+
+func (r *fakeMetricsRecorder) InFlight(_ RequestLabels, delta int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.inFlight += delta
+}
+
+func (r *fakeMetricsRecorder) ObserveRequest(m *RequestMetrics) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, m)
+}
+
+func (r *fakeMetricsRecorder) ObserveRetry(RequestLabels) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.retries++
+}
+
+func (r *fakeMetricsRecorder) CircuitStateChanged(name string, open bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.circuits[name] = open
+}
+
+func TestWithMetrics_Circuit(t *testing.T) {
+	recorder := &fakeMetricsRecorder{circuits: make(map[string]bool)}
+	c, err := New(AddCircuitConfig("partner"), WithMetrics(recorder))
+	ok(t, err)
+
+	cb := c.h.GetCircuit("partner")
+	cb.OpenCircuit()
+	assert(t, recorder.circuits["partner"], "expected the circuit to be reported open")
+	cb.CloseCircuit()
+	assert(t, !recorder.circuits["partner"], "expected the circuit to be reported closed")
+}
@@ -1,8 +1,12 @@
 package cast
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cep21/circuit/v3"
@@ -89,6 +93,17 @@ func WithRetry(retry int) Setter {
 	}
 }
 
+// WithBackoffStrategy changes the retry strategy to a custom one.
+func WithBackoffStrategy(stg BackoffStrategy) Setter {
+	return func(c *Cast) error {
+		if stg == nil {
+			return errors.New("backoff strategy must not be nil")
+		}
+		c.stg = stg
+		return nil
+	}
+}
+
 // WithLinearBackoffStrategy changes the retry strategy called "Linear".
 func WithLinearBackoffStrategy(slope time.Duration) Setter {
 	return func(c *Cast) error {
@@ -156,7 +171,6 @@ func WithExponentialBackoffDecorrelatedJitterStrategy(base, capacity time.Durati
 				base: base,
 				cap:  capacity,
 			},
-			base,
 		}
 		return nil
 	}
@@ -195,18 +209,31 @@ func AddBeforeRequestHook(hks ...BeforeRequestHook) Setter {
 }
 
 // WithLogHook sets a log callback when condition is achieved.
+// It only applies to the default logrus logger.
 func WithLogHook(f LogHook) Setter {
 	return func(c *Cast) error {
 		m := NewMonitor(f)
-		c.logger.AddHook(m)
+		c.logrus.AddHook(m)
 		return nil
 	}
 }
 
 // WithLogLevel sets log level.
+// It only applies to the default logrus logger.
 func WithLogLevel(l logrus.Level) Setter {
 	return func(c *Cast) error {
-		c.logger.SetLevel(l)
+		c.logrus.SetLevel(l)
+		return nil
+	}
+}
+
+// WithLogger replaces the default logrus logger, see NewLogrusLogger and NewSlogLogger.
+func WithLogger(logger Logger) Setter {
+	return func(c *Cast) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		c.logger = logger
 		return nil
 	}
 }
@@ -245,3 +272,417 @@ func AddRequestHook(hks ...RequestHook) Setter {
 		return nil
 	}
 }
+
+// WithHTTPClient replaces the underlying http client.
+// The transport knobs and WithHTTPClientTimeout are ignored once a client is supplied.
+// New fails if tls options are set as well, as they cannot be applied to the client.
+func WithHTTPClient(client *http.Client) Setter {
+	return func(c *Cast) error {
+		if client == nil {
+			return errors.New("http client must not be nil")
+		}
+		c.client = client
+		return nil
+	}
+}
+
+// WithTransport replaces the underlying http transport.
+func WithTransport(transport *http.Transport) Setter {
+	return func(c *Cast) error {
+		if transport == nil {
+			return errors.New("http transport must not be nil")
+		}
+		c.roundTripper = transport
+		return nil
+	}
+}
+
+// WithRoundTripper replaces the underlying round tripper, e.g. a custom middleware.
+// New fails if tls options are set and rt is not an *http.Transport.
+func WithRoundTripper(rt http.RoundTripper) Setter {
+	return func(c *Cast) error {
+		if rt == nil {
+			return errors.New("round tripper must not be nil")
+		}
+		c.roundTripper = rt
+		return nil
+	}
+}
+
+// WithDialTimeout sets the maximum amount of time a dial will wait for a connect to complete.
+func WithDialTimeout(timeout time.Duration) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.dialTimeout = timeout
+		return nil
+	}
+}
+
+// WithKeepAlive sets the interval between keep-alive probes for an active network connection.
+func WithKeepAlive(keepAlive time.Duration) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.keepAlive = keepAlive
+		return nil
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections across all hosts.
+func WithMaxIdleConns(n int) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.maxIdleConns = n
+		return nil
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum idle connections to keep per-host.
+func WithMaxIdleConnsPerHost(n int) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.maxIdleConnsPerHost = n
+		return nil
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections per host, zero means no limit.
+func WithMaxConnsPerHost(n int) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.maxConnsPerHost = n
+		return nil
+	}
+}
+
+// WithIdleConnTimeout sets the maximum amount of time an idle connection will remain idle before closing itself.
+func WithIdleConnTimeout(timeout time.Duration) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.idleConnTimeout = timeout
+		return nil
+	}
+}
+
+// WithTLSHandshakeTimeout sets the maximum amount of time waiting to wait for a TLS handshake.
+func WithTLSHandshakeTimeout(timeout time.Duration) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.tlsHandshakeTimeout = timeout
+		return nil
+	}
+}
+
+// WithResponseHeaderTimeout sets the amount of time to wait for a server's response headers
+// after fully writing the request.
+func WithResponseHeaderTimeout(timeout time.Duration) Setter {
+	return func(c *Cast) error {
+		c.transportConfig.responseHeaderTimeout = timeout
+		return nil
+	}
+}
+
+// WithTLSConfig replaces the tls config, the config is cloned.
+func WithTLSConfig(config *tls.Config) Setter {
+	return func(c *Cast) error {
+		if config == nil {
+			return errors.New("tls config must not be nil")
+		}
+		c.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithRootCAs sets the certificate authorities used to verify server certificates.
+func WithRootCAs(pool *x509.CertPool) Setter {
+	return func(c *Cast) error {
+		c.tlsClientConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// WithRootCAFiles loads PEM encoded certificate authorities used to verify server certificates.
+func WithRootCAFiles(files ...string) Setter {
+	return func(c *Cast) error {
+		pool, err := loadCertPool(files...)
+		if err != nil {
+			return err
+		}
+		c.tlsClientConfig().RootCAs = pool
+		return nil
+	}
+}
+
+// WithClientCertificate enables mutual TLS with a PEM encoded cert and key pair.
+// The pair is reloaded from disk whenever either file changes.
+func WithClientCertificate(certFile, keyFile string) Setter {
+	return func(c *Cast) error {
+		r, err := newCertificateReloader(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		c.tlsClientConfig().GetClientCertificate = r.GetClientCertificate
+		return nil
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version, e.g. tls.VersionTLS12.
+func WithMinTLSVersion(version uint16) Setter {
+	return func(c *Cast) error {
+		c.tlsClientConfig().MinVersion = version
+		return nil
+	}
+}
+
+// WithServerName overrides the server name used to verify the server certificate.
+func WithServerName(name string) Setter {
+	return func(c *Cast) error {
+		c.tlsClientConfig().ServerName = name
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify controls whether the server certificate chain and host name are verified.
+func WithInsecureSkipVerify(v bool) Setter {
+	return func(c *Cast) error {
+		c.tlsClientConfig().InsecureSkipVerify = v
+		return nil
+	}
+}
+
+// WithCache enables caching of GET and HEAD responses according to RFC 7234.
+func WithCache(store CacheStore) Setter {
+	return func(c *Cast) error {
+		if store == nil {
+			return errors.New("cache store must not be nil")
+		}
+		c.cache = store
+		return nil
+	}
+}
+
+// WithRateLimit limits every request of cast to rps requests per second with the given burst.
+// An rps of 0 means no limit, burst must be at least 1.
+func WithRateLimit(rps float64, burst int) Setter {
+	return func(c *Cast) error {
+		l, err := newLimiter(rps, burst)
+		if err != nil {
+			return err
+		}
+		c.rateLimiter = l
+		return nil
+	}
+}
+
+// WithHostRateLimit limits the requests to host, overriding WithRateLimit.
+// An rps of 0 exempts host from WithRateLimit.
+func WithHostRateLimit(host string, rps float64, burst int) Setter {
+	return func(c *Cast) error {
+		l, err := newLimiter(rps, burst)
+		if err != nil {
+			return err
+		}
+		c.hostLimiters[host] = l
+		return nil
+	}
+}
+
+// WithCircuitRateLimit limits the requests made with the named circuit,
+// overriding WithHostRateLimit and WithRateLimit. An rps of 0 exempts the circuit from them.
+func WithCircuitRateLimit(name string, rps float64, burst int) Setter {
+	return func(c *Cast) error {
+		l, err := newLimiter(rps, burst)
+		if err != nil {
+			return err
+		}
+		c.circuitLimiters[name] = l
+		return nil
+	}
+}
+
+// WithRateLimitMode sets whether a throttled request waits or fails fast, it waits by default.
+func WithRateLimitMode(mode RateLimitMode) Setter {
+	return func(c *Cast) error {
+		c.rateLimitMode = mode
+		return nil
+	}
+}
+
+// WithRetryStatusCodes retries the responses with the given status codes, e.g. 429, 502, 503 and 504.
+func WithRetryStatusCodes(codes ...int) Setter {
+	return func(c *Cast) error {
+		c.retryStatusCodes = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			c.retryStatusCodes[code] = true
+		}
+		return nil
+	}
+}
+
+// WithRetryIdempotentOnly only retries requests with idempotent methods or marked by Request.Idempotent.
+func WithRetryIdempotentOnly() Setter {
+	return func(c *Cast) error {
+		c.retryIdempotentOnly = true
+		return nil
+	}
+}
+
+// WithMaxRetryAfter caps the delay asked by the Retry-After response header, 30s by default.
+func WithMaxRetryAfter(d time.Duration) Setter {
+	return func(c *Cast) error {
+		c.maxRetryAfter = d
+		return nil
+	}
+}
+
+// WithRetryBudget caps retries at ratio of the requests made within window,
+// e.g. 0.1 for 10%, while always allowing minRetries per window.
+func WithRetryBudget(ratio float64, window time.Duration, minRetries int) Setter {
+	return func(c *Cast) error {
+		if window <= 0 {
+			return errors.New("retry budget window must be positive")
+		}
+		c.retryBudget = newRetryBudget(ratio, window, minRetries)
+		return nil
+	}
+}
+
+// WithMaxElapsedTime bounds a request, all its attempts and backoffs included, by d since the first attempt.
+// No retry is started if it would begin after that deadline.
+func WithMaxElapsedTime(d time.Duration) Setter {
+	return func(c *Cast) error {
+		c.maxElapsedTime = d
+		return nil
+	}
+}
+
+// WithTracer replaces the tracer, the opentracing global tracer is used by default.
+func WithTracer(tracer Tracer) Setter {
+	return func(c *Cast) error {
+		if tracer == nil {
+			return errors.New("tracer must not be nil")
+		}
+		c.tracer = tracer
+		return nil
+	}
+}
+
+// WithMetrics sets the recorder of request, retry and circuit metrics.
+func WithMetrics(recorder MetricsRecorder) Setter {
+	return func(c *Cast) error {
+		if recorder == nil {
+			return errors.New("metrics recorder must not be nil")
+		}
+		c.metrics = recorder
+		return nil
+	}
+}
+
+// WithDump sets what is logged for every response, DumpStd by default, no flag disables the dump.
+func WithDump(flags ...DumpFlag) Setter {
+	return func(c *Cast) error {
+		c.dumpFlag = dumpFlags(flags)
+		return nil
+	}
+}
+
+// WithDumpBodyLimit sets the number of bytes of a body logged before it is truncated,
+// 8192 by default, a negative limit means no limit.
+func WithDumpBodyLimit(limit int) Setter {
+	return func(c *Cast) error {
+		c.dumpBodyLimit = limit
+		return nil
+	}
+}
+
+// WithRedactHeaders masks the given headers in logs and dumps,
+// in addition to Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Api-Key, X-Auth-Token
+// and X-Amz-Security-Token.
+func WithRedactHeaders(names ...string) Setter {
+	return func(c *Cast) error {
+		c.redactor.addHeaders(names...)
+		return nil
+	}
+}
+
+// WithRedactQueryParams masks the given query parameters, and form fields, in logs and dumps.
+func WithRedactQueryParams(names ...string) Setter {
+	return func(c *Cast) error {
+		for _, name := range names {
+			c.redactor.queryParams[name] = true
+		}
+		return nil
+	}
+}
+
+// WithRedactJSONFields masks the given JSON fields of request and response bodies in logs and dumps.
+// A path is dot separated from the root, "*" matches any key or array element,
+// and a path without a dot, e.g. "password", matches the field at any depth.
+func WithRedactJSONFields(paths ...string) Setter {
+	return func(c *Cast) error {
+		c.redactor.addJSONFields(paths...)
+		return nil
+	}
+}
+
+// WithRedactor sets a custom redaction applied after the built-in rules.
+func WithRedactor(f RedactFunc) Setter {
+	return func(c *Cast) error {
+		c.redactor.custom = f
+		return nil
+	}
+}
+
+// WithRecorder records the interactions of cast to a cassette file, or replays them from it,
+// depending on mode. The cassette is YAML if path ends with .yaml or .yml, JSON otherwise.
+// Requests are matched by method and url unless matchers are given,
+// secrets are redacted as in logs before anything is written.
+func WithRecorder(path string, mode RecordMode, matchers ...RecordMatcher) Setter {
+	return func(c *Cast) error {
+		r, err := newRecorder(path, mode, matchers)
+		if err != nil {
+			return err
+		}
+		c.recorder = r
+		return nil
+	}
+}
+
+// WithErrorOnStatus makes Do return an *HTTPError for every non-2xx response.
+func WithErrorOnStatus() Setter {
+	return func(c *Cast) error {
+		c.errorOnStatus = true
+		return nil
+	}
+}
+
+// WithCodec registers a codec for its content type and the extra content types given,
+// replacing the built-in JSON, XML or YAML codec if any.
+// Registered codecs are used by WithJSONBody, WithXMLBody, Request.WithBody, Response.Decode and Call.
+func WithCodec(codec Codec, contentTypes ...string) Setter {
+	return func(c *Cast) error {
+		if codec == nil {
+			return errors.New("codec must not be nil")
+		}
+		return c.codecs.register(codec, contentTypes...)
+	}
+}
+
+// WithAcceptEncoding negotiates the given encodings, in order of preference, among
+// EncodingBrotli, EncodingZstd, EncodingGzip and EncodingDeflate.
+// Compressed responses are decoded whatever the option, Response.Body returns the decoded body.
+func WithAcceptEncoding(encodings ...string) Setter {
+	return func(c *Cast) error {
+		for _, e := range encodings {
+			if err := checkEncoding(e); err != nil {
+				return err
+			}
+		}
+		c.acceptEncoding = strings.Join(encodings, ", ")
+		return nil
+	}
+}
+
+// WithSigner signs every request, e.g. with an *AWSSigner or an *HMACSigner,
+// once its headers and query params are final, and again before every retry.
+func WithSigner(signer Signer) Setter {
+	return func(c *Cast) error {
+		if signer == nil {
+			return errors.New("signer must not be nil")
+		}
+		c.signer = signer
+		return nil
+	}
+}
@@ -152,3 +152,49 @@ func TestWithHttpClientTimeout(t *testing.T) {
 	}
 
 }
+
+func TestWithTransportKnobs(t *testing.T) {
+	c, err := New(
+		WithDialTimeout(time.Second),
+		WithMaxIdleConnsPerHost(10),
+		WithResponseHeaderTimeout(2*time.Second),
+	)
+	ok(t, err)
+	transport, isTransport := c.client.Transport.(*http.Transport)
+	assert(t, isTransport, "unexpected transport type")
+	assert(t, transport.MaxIdleConnsPerHost == 10, "unexpected MaxIdleConnsPerHost")
+	assert(t, transport.MaxIdleConns == defaultMaxIdleConns, "unexpected MaxIdleConns")
+	assert(t, transport.ResponseHeaderTimeout == 2*time.Second, "unexpected ResponseHeaderTimeout")
+	assert(t, c.client.Timeout == 10*time.Second, "unexpected client timeout")
+}
+
+func TestWithRoundTripper(t *testing.T) {
+	rt := http.DefaultTransport
+	c, err := New(WithRoundTripper(rt))
+	ok(t, err)
+	assert(t, c.client.Transport == rt, "unexpected round tripper")
+
+	_, err = New(WithRoundTripper(nil))
+	assert(t, err != nil, "expected error for nil round tripper")
+}
+
+func TestWithHTTPClient(t *testing.T) {
+	client := &http.Client{}
+	c, err := New(WithHTTPClient(client), WithDialTimeout(time.Second))
+	ok(t, err)
+	assert(t, c.client == client, "unexpected http client")
+}
+
+func TestWithRoundTripper_NotMutated(t *testing.T) {
+	rt := &http.Transport{}
+	c, err := New(WithRoundTripper(rt), WithServerName("internal"))
+	ok(t, err)
+	assert(t, rt.TLSClientConfig == nil || rt.TLSClientConfig.ServerName == "", "unexpected tls config on the supplied transport")
+	assert(t, c.client.Transport.(*http.Transport).TLSClientConfig.ServerName == "internal", "expected the tls config on a clone")
+
+	c, err = New(WithRoundTripper(rt))
+	ok(t, err)
+	ok(t, c.SetInsecureSkipVerify(true))
+	assert(t, rt.TLSClientConfig == nil || !rt.TLSClientConfig.InsecureSkipVerify, "unexpected tls config on the supplied transport")
+	assert(t, c.client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify, "expected InsecureSkipVerify")
+}
@@ -0,0 +1,100 @@
+// Package otelcast adapts OpenTelemetry tracing to cast.
+package otelcast
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/xiaojiaoyu100/cast"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/xiaojiaoyu100/cast"
+
+// Option configures the tracer.
+type Option func(t *tracer)
+
+// WithTracerProvider sets the tracer provider, otel.GetTracerProvider() by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *tracer) {
+		t.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator, the W3C trace context and baggage by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(t *tracer) {
+		t.propagator = propagator
+	}
+}
+
+type tracer struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+	tracer     trace.Tracer
+}
+
+// NewTracer returns a cast.Tracer backed by OpenTelemetry.
+func NewTracer(opts ...Option) cast.Tracer {
+	t := &tracer{
+		provider:   otel.GetTracerProvider(),
+		propagator: propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	t.tracer = t.provider.Tracer(instrumentationName)
+	return t
+}
+
+func (t *tracer) Start(ctx context.Context, name string) (context.Context, cast.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, &otelSpan{span: span}
+}
+
+func (t *tracer) Inject(ctx context.Context, header http.Header) error {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+	return nil
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s *otelSpan) SetAttribute(key string, value interface{}) {
+	var kv attribute.KeyValue
+	switch v := value.(type) {
+	case string:
+		kv = attribute.String(key, v)
+	case int:
+		kv = attribute.Int(key, v)
+	case int64:
+		kv = attribute.Int64(key, v)
+	case bool:
+		kv = attribute.Bool(key, v)
+	case float64:
+		kv = attribute.Float64(key, v)
+	default:
+		kv = attribute.String(key, fmt.Sprint(v))
+	}
+	s.span.SetAttributes(kv)
+}
+
+func (s *otelSpan) AddEvent(name string, at time.Time) {
+	s.span.AddEvent(name, trace.WithTimestamp(at))
+}
+
+func (s *otelSpan) SetError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s *otelSpan) End() {
+	s.span.End()
+}
@@ -0,0 +1,51 @@
+package otelcast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xiaojiaoyu100/cast"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	var traceparent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	c, err := cast.New(cast.WithBaseURL(ts.URL), cast.WithTracer(NewTracer(WithTracerProvider(provider))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected spans %d", len(spans))
+	}
+	attempt, do := spans[0], spans[1]
+	if attempt.Parent().SpanID() != do.SpanContext().SpanID() {
+		t.Fatal("expected the attempt span to be a child of the request span")
+	}
+	if len(traceparent) == 0 || traceparent[36:52] != attempt.SpanContext().SpanID().String() {
+		t.Fatalf("unexpected traceparent %q", traceparent)
+	}
+	if do.Status().Code != codes.Error {
+		t.Fatal("expected the request span to be marked as error")
+	}
+	if len(attempt.Events()) == 0 {
+		t.Fatal("expected timing events")
+	}
+}
@@ -0,0 +1,153 @@
+// Package promcast records the metrics of cast with Prometheus.
+package promcast
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/xiaojiaoyu100/cast"
+)
+
+// Recorder is a cast.MetricsRecorder backed by Prometheus collectors.
+type Recorder struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	phases   *prometheus.HistogramVec
+	retries  *prometheus.CounterVec
+	inFlight *prometheus.GaugeVec
+	circuits *prometheus.CounterVec
+	open     *prometheus.GaugeVec
+}
+
+// Option configures the recorder.
+type Option func(o *options)
+
+type options struct {
+	namespace string
+	buckets   []float64
+}
+
+// WithNamespace sets the namespace of the metrics, "cast" by default.
+func WithNamespace(namespace string) Option {
+	return func(o *options) {
+		o.namespace = namespace
+	}
+}
+
+// WithBuckets sets the buckets of the latency histograms in seconds.
+func WithBuckets(buckets []float64) Option {
+	return func(o *options) {
+		o.buckets = buckets
+	}
+}
+
+// NewRecorder creates the collectors and registers them to registerer.
+func NewRecorder(registerer prometheus.Registerer, opts ...Option) (*Recorder, error) {
+	o := &options{
+		namespace: "cast",
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	labels := []string{"method", "host", "route"}
+	r := &Recorder{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "requests_total",
+			Help:      "Number of requests by status class.",
+		}, append(labels, "status")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests, retries included.",
+			Buckets:   o.buckets,
+		}, append(labels, "status")),
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: o.namespace,
+			Name:      "request_phase_duration_seconds",
+			Help:      "Latency of the phases of the last attempt: dns, connect, tls, sending, waiting and receiving.",
+			Buckets:   o.buckets,
+		}, append(labels, "phase")),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "retries_total",
+			Help:      "Number of retries.",
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Name:      "requests_in_flight",
+			Help:      "Number of requests in flight.",
+		}, labels),
+		circuits: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: o.namespace,
+			Name:      "circuit_transitions_total",
+			Help:      "Number of circuit transitions by state.",
+		}, []string{"circuit", "state"}),
+		open: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: o.namespace,
+			Name:      "circuit_open",
+			Help:      "Whether the circuit is open.",
+		}, []string{"circuit"}),
+	}
+	for _, c := range []prometheus.Collector{r.requests, r.duration, r.phases, r.retries, r.inFlight, r.circuits, r.open} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func statusClass(m *cast.RequestMetrics) string {
+	if m.Err != nil || m.StatusCode == 0 {
+		return "error"
+	}
+	return strconv.Itoa(m.StatusCode/100) + "xx"
+}
+
+// InFlight implements cast.MetricsRecorder.
+func (r *Recorder) InFlight(labels cast.RequestLabels, delta int) {
+	r.inFlight.WithLabelValues(labels.Method, labels.Host, labels.Route).Add(float64(delta))
+}
+
+// ObserveRequest implements cast.MetricsRecorder.
+func (r *Recorder) ObserveRequest(m *cast.RequestMetrics) {
+	status := statusClass(m)
+	r.requests.WithLabelValues(m.Method, m.Host, m.Route, status).Inc()
+	r.duration.WithLabelValues(m.Method, m.Host, m.Route, status).Observe(m.Duration.Seconds())
+	if m.FromCache || m.Attempts == 0 {
+		return
+	}
+	phases := []struct {
+		name  string
+		value float64
+	}{
+		{"dns", m.DNS.Seconds()},
+		{"connect", m.Connect.Seconds()},
+		{"tls", m.TLS.Seconds()},
+		{"sending", m.Sending.Seconds()},
+		{"waiting", m.Waiting.Seconds()},
+		{"receiving", m.Receiving.Seconds()},
+	}
+	for _, p := range phases {
+		if p.value > 0 {
+			r.phases.WithLabelValues(m.Method, m.Host, m.Route, p.name).Observe(p.value)
+		}
+	}
+}
+
+// ObserveRetry implements cast.MetricsRecorder.
+func (r *Recorder) ObserveRetry(labels cast.RequestLabels) {
+	r.retries.WithLabelValues(labels.Method, labels.Host, labels.Route).Inc()
+}
+
+// CircuitStateChanged implements cast.MetricsRecorder.
+func (r *Recorder) CircuitStateChanged(name string, open bool) {
+	state, v := "closed", 0.0
+	if open {
+		state, v = "open", 1.0
+	}
+	r.circuits.WithLabelValues(name, state).Inc()
+	r.open.WithLabelValues(name).Set(v)
+}
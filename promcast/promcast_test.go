@@ -0,0 +1,50 @@
+package promcast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/xiaojiaoyu100/cast"
+)
+
+func TestRecorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	registry := prometheus.NewRegistry()
+	recorder, err := NewRecorder(registry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cast.New(cast.WithBaseURL(ts.URL), cast.WithMetrics(recorder), cast.WithRetry(1),
+		cast.WithRetryStatusCodes(http.StatusServiceUnavailable), cast.WithConstantBackoffStrategy(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := c.NewRequest().WithPath("/users/{id}").WithPathParam(map[string]interface{}{"id": 1})
+	if _, err := c.Do(context.TODO(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	host := ts.Listener.Addr().String()
+	if v := testutil.ToFloat64(recorder.requests.WithLabelValues("GET", host, "/users/{id}", "5xx")); v != 1 {
+		t.Fatalf("unexpected requests %v", v)
+	}
+	if v := testutil.ToFloat64(recorder.retries.WithLabelValues("GET", host, "/users/{id}")); v != 1 {
+		t.Fatalf("unexpected retries %v", v)
+	}
+	if v := testutil.ToFloat64(recorder.inFlight.WithLabelValues("GET", host, "/users/{id}")); v != 0 {
+		t.Fatalf("unexpected in flight %v", v)
+	}
+
+	recorder.CircuitStateChanged("partner", true)
+	if v := testutil.ToFloat64(recorder.open.WithLabelValues("partner")); v != 1 {
+		t.Fatalf("unexpected circuit state %v", v)
+	}
+}
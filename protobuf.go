@@ -0,0 +1,94 @@
+package cast
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string { return applicationProtobuf }
+
+func (protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("cast: %T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cast: %T is not a proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
+
+// ProtobufCodec encodes proto messages in the protobuf wire format, registered by default.
+var ProtobufCodec Codec = protobufCodec{}
+
+type requestProtobufBody struct {
+	payload proto.Message
+}
+
+func (body *requestProtobufBody) ContentType() string {
+	return applicationProtobuf
+}
+
+func (body *requestProtobufBody) Body() ([]byte, error) {
+	if body.payload == nil {
+		return []byte{}, nil
+	}
+	return proto.Marshal(body.payload)
+}
+
+type requestProtoJSONBody struct {
+	payload proto.Message
+}
+
+func (body *requestProtoJSONBody) ContentType() string {
+	return applicationJSON
+}
+
+func (body *requestProtoJSONBody) Body() ([]byte, error) {
+	if body.payload == nil {
+		return []byte{}, nil
+	}
+	return protojson.Marshal(body.payload)
+}
+
+// WithProtobufBody creates body with protobuf.
+func (r *Request) WithProtobufBody(body proto.Message) *Request {
+	r.body = &requestProtobufBody{
+		payload: body,
+	}
+	return r
+}
+
+// WithProtoJSONBody creates body with the JSON mapping of protobuf.
+func (r *Request) WithProtoJSONBody(body proto.Message) *Request {
+	r.body = &requestProtoJSONBody{
+		payload: body,
+	}
+	return r
+}
+
+// DecodeFromProtobuf decodes the protobuf body into m.
+func (resp *Response) DecodeFromProtobuf(m proto.Message) error {
+	if len(resp.body) == 0 {
+		return nil
+	}
+	return proto.Unmarshal(resp.body, m)
+}
+
+// DecodeFromProtoJSON decodes the JSON body into m with the JSON mapping of protobuf,
+// unknown fields are discarded.
+func (resp *Response) DecodeFromProtoJSON(m proto.Message) error {
+	if len(resp.body) == 0 {
+		return nil
+	}
+	return protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(resp.body, m)
+}
@@ -0,0 +1,41 @@
+package cast
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobuf(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+
+	resp, err := c.Do(context.TODO(), c.NewRequest().Post().WithProtobufBody(wrapperspb.String("cast")))
+	ok(t, err)
+	assert(t, resp.Header().Get("Content-Type") == applicationProtobuf, "unexpected content type %s", resp.Header().Get("Content-Type"))
+	var m wrapperspb.StringValue
+	ok(t, resp.DecodeFromProtobuf(&m))
+	assert(t, m.GetValue() == "cast", "unexpected message %v", &m)
+
+	var decoded wrapperspb.StringValue
+	ok(t, resp.Decode(&decoded))
+	assert(t, decoded.GetValue() == "cast", "unexpected message %v", &decoded)
+
+	resp, err = c.Do(context.TODO(), c.NewRequest().Post().WithProtoJSONBody(wrapperspb.Int64(42)))
+	ok(t, err)
+	assert(t, resp.String() == `"42"`, "unexpected body %s", resp.String())
+	var n wrapperspb.Int64Value
+	ok(t, resp.DecodeFromProtoJSON(&n))
+	assert(t, n.GetValue() == 42, "unexpected message %v", &n)
+}
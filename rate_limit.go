@@ -0,0 +1,92 @@
+package cast
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimitMode decides what happens when a request exceeds the rate limit.
+type RateLimitMode int
+
+const (
+	// RateLimitWait waits for a token, giving up early if the request context
+	// deadline would be exceeded.
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast fails immediately with a *RateLimitError.
+	RateLimitFailFast
+)
+
+// RateLimitError is returned when a request is throttled by the client side rate limit.
+type RateLimitError struct {
+	// Key is the circuit name or host of the limiter, empty for the cast limiter.
+	Key string
+	Err error
+}
+
+func (err *RateLimitError) Error() string {
+	if err.Err != nil {
+		return fmt.Sprintf("cast: rate limit %q exceeded: %s", err.Key, err.Err)
+	}
+	return fmt.Sprintf("cast: rate limit %q exceeded", err.Key)
+}
+
+// Unwrap returns the underlying error.
+func (err *RateLimitError) Unwrap() error {
+	return err.Err
+}
+
+// newLimiter returns a limiter of rps requests per second, unlimited if rps is 0.
+func newLimiter(rps float64, burst int) (*rate.Limiter, error) {
+	if rps < 0 {
+		return nil, errors.New("rate limit rps must not be negative")
+	}
+	if burst < 1 {
+		return nil, errors.New("rate limit burst must be at least 1")
+	}
+	limit := rate.Limit(rps)
+	if rps == 0 {
+		limit = rate.Inf
+	}
+	return rate.NewLimiter(limit, burst), nil
+}
+
+// limiter picks the circuit limiter first, then the host limiter, then the cast limiter.
+func (c *Cast) limiter(request *Request) (string, *rate.Limiter) {
+	name := request.circuitName
+	if len(name) == 0 {
+		name = c.defaultCircuitName
+	}
+	if l, ok := c.circuitLimiters[name]; ok && len(name) > 0 {
+		return name, l
+	}
+	host := request.rawRequest.URL.Host
+	if l, ok := c.hostLimiters[host]; ok {
+		return host, l
+	}
+	return "", c.rateLimiter
+}
+
+func (c *Cast) waitRateLimit(request *Request) error {
+	key, l := c.limiter(request)
+	if l == nil {
+		return nil
+	}
+	switch c.rateLimitMode {
+	case RateLimitFailFast:
+		if !l.Allow() {
+			return &RateLimitError{Key: key}
+		}
+	default:
+		start := time.Now()
+		if err := l.Wait(request.rawRequest.Context()); err != nil {
+			return &RateLimitError{Key: key, Err: err}
+		}
+		if waited := time.Since(start); waited > time.Millisecond {
+			c.logger.Debug("cast: rate limit waited", field("key", key), field("duration", waited))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCast_RateLimitFailFast(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRateLimit(0.001, 1), WithRateLimitMode(RateLimitFailFast))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	_, isRateLimit := err.(*RateLimitError)
+	assert(t, isRateLimit, "expected *RateLimitError, got %v", err)
+}
+
+func TestCast_RateLimitWaitDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	ok(t, err)
+
+	c, err := New(WithBaseURL(ts.URL), WithRateLimit(1000, 1000), WithHostRateLimit(u.Host, 0.001, 1))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = c.Do(ctx, c.NewRequest())
+	rateLimitErr, isRateLimit := err.(*RateLimitError)
+	assert(t, isRateLimit, "expected *RateLimitError, got %v", err)
+	assert(t, rateLimitErr.Key == u.Host, "unexpected key: %s", rateLimitErr.Key)
+	assert(t, time.Since(start) < 50*time.Millisecond, "expected to fail before the deadline")
+}
+
+func TestWithRateLimit_Validation(t *testing.T) {
+	_, err := New(WithRateLimit(10, 0))
+	assert(t, err != nil, "expected an error for a zero burst")
+	_, err = New(WithHostRateLimit("example.com", -1, 1))
+	assert(t, err != nil, "expected an error for a negative rps")
+	_, err = New(WithCircuitRateLimit("circuit", 1, -1))
+	assert(t, err != nil, "expected an error for a negative burst")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	ok(t, err)
+	c, err := New(WithBaseURL(ts.URL), WithRateLimit(0.001, 1), WithHostRateLimit(u.Host, 0, 1),
+		WithRateLimitMode(RateLimitFailFast))
+	ok(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = c.Do(context.TODO(), c.NewRequest())
+		ok(t, err)
+	}
+}
@@ -0,0 +1,285 @@
+package cast
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RecordMode decides whether the recorder hits the network.
+type RecordMode int
+
+const (
+	// RecordReplay serves the cassette only and never hits the network,
+	// an unmatched request fails with ErrInteractionNotFound.
+	RecordReplay RecordMode = iota
+	// RecordRecord hits the network and records every interaction, replacing the cassette.
+	RecordRecord
+	// RecordReplayOrRecord serves matched requests from the cassette and records the others.
+	RecordReplayOrRecord
+)
+
+// ErrInteractionNotFound is returned in replay mode when no recorded interaction matches a request.
+const ErrInteractionNotFound Error = "cast: recorded interaction not found"
+
+// BodyEncodingBase64 marks a recorded body which is not valid UTF-8, e.g. compressed or protobuf,
+// and is stored base64 encoded without redaction.
+const BodyEncodingBase64 = "base64"
+
+// RecordedRequest is a request as stored in a cassette, after redaction.
+type RecordedRequest struct {
+	Method       string      `json:"method" yaml:"method"`
+	URL          string      `json:"url" yaml:"url"`
+	Header       http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// RecordedResponse is a response as stored in a cassette, after redaction.
+type RecordedResponse struct {
+	StatusCode   int         `json:"status_code" yaml:"status_code"`
+	Header       http.Header `json:"header,omitempty" yaml:"header,omitempty"`
+	Body         string      `json:"body,omitempty" yaml:"body,omitempty"`
+	BodyEncoding string      `json:"body_encoding,omitempty" yaml:"body_encoding,omitempty"`
+}
+
+// Interaction is a recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request" yaml:"request"`
+	Response RecordedResponse `json:"response" yaml:"response"`
+}
+
+type cassette struct {
+	Interactions []*Interaction `json:"interactions" yaml:"interactions"`
+}
+
+// RecordMatcher reports whether a recorded request matches an outgoing one.
+type RecordMatcher func(req, recorded *RecordedRequest) bool
+
+// MatchMethod matches the request method.
+func MatchMethod(req, recorded *RecordedRequest) bool {
+	return req.Method == recorded.Method
+}
+
+// MatchURL matches the request url, query included.
+func MatchURL(req, recorded *RecordedRequest) bool {
+	return req.URL == recorded.URL
+}
+
+// MatchBody matches the request body.
+func MatchBody(req, recorded *RecordedRequest) bool {
+	return req.Body == recorded.Body && req.BodyEncoding == recorded.BodyEncoding
+}
+
+// MatchHeaders matches the given request headers.
+func MatchHeaders(names ...string) RecordMatcher {
+	return func(req, recorded *RecordedRequest) bool {
+		for _, name := range names {
+			if strings.Join(req.Header.Values(name), ",") != strings.Join(recorded.Header.Values(name), ",") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// recorder is a round tripper recording interactions to, or replaying them from, a cassette file.
+// Bodies are buffered in memory, the cassette is rewritten after every recorded interaction.
+type recorder struct {
+	path     string
+	mode     RecordMode
+	matchers []RecordMatcher
+	redactor *redactor
+	next     http.RoundTripper
+
+	mu       sync.Mutex
+	cassette cassette
+	used     map[*Interaction]bool
+}
+
+func newRecorder(path string, mode RecordMode, matchers []RecordMatcher) (*recorder, error) {
+	if len(matchers) == 0 {
+		matchers = []RecordMatcher{MatchMethod, MatchURL}
+	}
+	r := &recorder{
+		path:     path,
+		mode:     mode,
+		matchers: matchers,
+		used:     make(map[*Interaction]bool),
+	}
+	if mode == RecordRecord {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err) && mode == RecordReplayOrRecord:
+		return r, nil
+	case err != nil:
+		return nil, err
+	}
+	if err := r.unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("cast: decode cassette %s: %w", path, err)
+	}
+	return r, nil
+}
+
+func (r *recorder) isYAML() bool {
+	ext := filepath.Ext(r.path)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+func (r *recorder) unmarshal(data []byte, v interface{}) error {
+	if r.isYAML() {
+		return yaml.Unmarshal(data, v)
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (r *recorder) marshal(v interface{}) ([]byte, error) {
+	if r.isYAML() {
+		return yaml.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := &RecordedRequest{
+		Method: req.Method,
+		URL:    r.redactor.url(req.URL),
+		Header: r.redactor.header(req.Header),
+	}
+	recorded.Body, recorded.BodyEncoding = r.body(req.Header.Get(contentType), body)
+
+	if r.mode != RecordRecord {
+		if interaction := r.match(recorded); interaction != nil {
+			return interaction.Response.httpResponse(req), nil
+		}
+		if r.mode == RecordReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, recorded.Method, recorded.URL)
+		}
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := &Interaction{
+		Request: *recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.redactor.header(resp.Header),
+		},
+	}
+	interaction.Response.Body, interaction.Response.BodyEncoding = r.body(resp.Header.Get(contentType), respBody)
+	if err := r.save(interaction); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// body returns the body to record, redacted if it is text and base64 encoded otherwise.
+func (r *recorder) body(contentType string, body []byte) (string, string) {
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), BodyEncodingBase64
+	}
+	return r.redactor.body(contentType, body), ""
+}
+
+// match returns the first unused matching interaction, or the last matching one
+// so that a request sent more often than recorded is still served.
+func (r *recorder) match(req *RecordedRequest) *Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var last *Interaction
+	for _, interaction := range r.cassette.Interactions {
+		if !r.matches(req, &interaction.Request) {
+			continue
+		}
+		if !r.used[interaction] {
+			r.used[interaction] = true
+			return interaction
+		}
+		last = interaction
+	}
+	return last
+}
+
+func (r *recorder) matches(req, recorded *RecordedRequest) bool {
+	for _, m := range r.matchers {
+		if !m(req, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *recorder) save(interaction *Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.used[interaction] = true
+	data, err := r.marshal(&r.cassette)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+func (rr *RecordedResponse) httpResponse(req *http.Request) *http.Response {
+	header := rr.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	body := []byte(rr.Body)
+	if rr.BodyEncoding == BodyEncodingBase64 {
+		if decoded, err := base64.StdEncoding.DecodeString(rr.Body); err == nil {
+			body = decoded
+		}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rr.StatusCode, http.StatusText(rr.StatusCode)),
+		StatusCode:    rr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
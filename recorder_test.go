@@ -0,0 +1,117 @@
+package cast
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordReplay(t *testing.T) {
+	for _, name := range []string{"cassette.json", "cassette.yaml"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+
+			var calls int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"id":"` + r.URL.Query().Get("id") + `"}`))
+			}))
+
+			c, err := New(WithBaseURL(ts.URL), WithRecorder(path, RecordRecord))
+			ok(t, err)
+			resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/users?id=1").SetHeader("Authorization", "Bearer secret"))
+			ok(t, err)
+			assert(t, resp.String() == `{"id":"1"}`, "unexpected body %s", resp.String())
+			ts.Close()
+
+			data, err := os.ReadFile(path)
+			ok(t, err)
+			assert(t, !strings.Contains(string(data), "secret"), "cassette leaks a secret: %s", data)
+
+			c, err = New(WithBaseURL(ts.URL), WithRecorder(path, RecordReplay))
+			ok(t, err)
+			resp, err = c.Do(context.TODO(), c.NewRequest().WithPath("/users?id=1"))
+			ok(t, err)
+			assert(t, resp.StatusCode() == http.StatusOK, "unexpected status code %d", resp.StatusCode())
+			assert(t, resp.String() == `{"id":"1"}`, "unexpected body %s", resp.String())
+			assert(t, calls == 1, "unexpected calls %d", calls)
+
+			_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/users?id=2"))
+			assert(t, errors.Is(err, ErrInteractionNotFound), "unexpected error %v", err)
+		})
+	}
+}
+
+func TestRecorder_Matchers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Tenant")))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRecorder(path, RecordReplayOrRecord, MatchMethod, MatchURL, MatchHeaders("X-Tenant")))
+	ok(t, err)
+	for _, tenant := range []string{"a", "b", "a"} {
+		resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/").SetHeader("X-Tenant", tenant))
+		ok(t, err)
+		assert(t, resp.String() == tenant, "unexpected body %s", resp.String())
+	}
+
+	c, err = New(WithBaseURL(ts.URL), WithRecorder(path, RecordReplay))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	ok(t, err)
+	assert(t, resp.String() == "a", "unexpected body %s", resp.String())
+}
+
+func TestRecorder_BinaryBody(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	var payload bytes.Buffer
+	gz := gzip.NewWriter(&payload)
+	_, _ = gz.Write([]byte("compressed"))
+	_ = gz.Close()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(contentType, "application/gzip")
+		_, _ = w.Write(payload.Bytes())
+	}))
+
+	c, err := New(WithBaseURL(ts.URL), WithRecorder(path, RecordRecord))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, bytes.Equal(resp.Body(), payload.Bytes()), "unexpected body %v", resp.Body())
+	ts.Close()
+
+	data, err := os.ReadFile(path)
+	ok(t, err)
+	assert(t, strings.Contains(string(data), "body_encoding: base64"), "expected a base64 body: %s", data)
+
+	c, err = New(WithBaseURL(ts.URL), WithRecorder(path, RecordReplay))
+	ok(t, err)
+	resp, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, bytes.Equal(resp.Body(), payload.Bytes()), "unexpected body %v", resp.Body())
+}
+
+func TestRecorder_InsecureSkipVerify(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithHTTPClient(&http.Client{}),
+		WithRecorder(filepath.Join(t.TempDir(), "cassette.json"), RecordRecord))
+	ok(t, err)
+	ok(t, c.SetInsecureSkipVerify(true))
+	resp, err := c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, resp.String() == "ok", "unexpected body %s", resp.String())
+}
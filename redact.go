@@ -0,0 +1,211 @@
+package cast
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var defaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Auth-Token",
+	"X-Amz-Security-Token",
+}
+
+// Redact kinds passed to a RedactFunc.
+const (
+	RedactHeader = "header"
+	RedactQuery  = "query"
+	RedactBody   = "body"
+)
+
+// RedactFunc redacts a value before it is logged, after the built-in rules ran.
+// kind is RedactHeader, RedactQuery or RedactBody, name is the header or parameter name,
+// empty for a body.
+type RedactFunc func(kind, name, value string) string
+
+// redactor masks secrets in everything cast logs.
+type redactor struct {
+	headers     map[string]bool
+	queryParams map[string]bool
+	jsonFields  map[string]bool
+	jsonPaths   [][]string
+	custom      RedactFunc
+}
+
+func newRedactor() *redactor {
+	r := &redactor{
+		headers:     make(map[string]bool),
+		queryParams: make(map[string]bool),
+		jsonFields:  make(map[string]bool),
+	}
+	r.addHeaders(defaultRedactHeaders...)
+	return r
+}
+
+func (r *redactor) addHeaders(names ...string) {
+	for _, name := range names {
+		r.headers[http.CanonicalHeaderKey(name)] = true
+	}
+}
+
+// addJSONFields adds dot separated paths from the root, "*" matching any key or index.
+// A path without a dot matches the field at any depth.
+func (r *redactor) addJSONFields(paths ...string) {
+	for _, path := range paths {
+		if !strings.Contains(path, ".") {
+			r.jsonFields[path] = true
+			continue
+		}
+		r.jsonPaths = append(r.jsonPaths, strings.Split(path, "."))
+	}
+}
+
+func (r *redactor) value(kind, name, value string) string {
+	if r.custom == nil {
+		return value
+	}
+	return r.custom(kind, name, value)
+}
+
+func (r *redactor) header(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	redactedHeader := make(http.Header, len(h))
+	for k, vv := range h {
+		values := make([]string, len(vv))
+		for i, v := range vv {
+			if r.headers[http.CanonicalHeaderKey(k)] {
+				v = redacted
+			}
+			values[i] = r.value(RedactHeader, k, v)
+		}
+		redactedHeader[k] = values
+	}
+	return redactedHeader
+}
+
+func (r *redactor) values(values url.Values) url.Values {
+	redactedValues := make(url.Values, len(values))
+	for k, vv := range values {
+		v2 := make([]string, len(vv))
+		for i, v := range vv {
+			if r.queryParams[k] {
+				v = redacted
+			}
+			v2[i] = r.value(RedactQuery, k, v)
+		}
+		redactedValues[k] = v2
+	}
+	return redactedValues
+}
+
+func (r *redactor) url(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	if len(u.RawQuery) == 0 && u.User == nil {
+		return u.String()
+	}
+	values, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return u.String()
+	}
+	u2 := *u
+	if u.User != nil {
+		u2.User = url.User(u.User.Username())
+	}
+	u2.RawQuery = r.values(values).Encode()
+	return u2.String()
+}
+
+// err masks the url of a *url.Error, as returned by http.Client.
+func (r *redactor) err(err error) error {
+	urlErr, ok := err.(*url.Error)
+	if !ok {
+		return err
+	}
+	u, parseErr := url.Parse(urlErr.URL)
+	if parseErr != nil {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: r.url(u), Err: urlErr.Err}
+}
+
+func (r *redactor) body(contentType string, body []byte) string {
+	s := string(body)
+	switch {
+	case strings.Contains(contentType, "json"):
+		s = r.json(body)
+	case strings.HasPrefix(contentType, formURLEncoded):
+		if values, err := url.ParseQuery(s); err == nil {
+			s = r.values(values).Encode()
+		}
+	}
+	return r.value(RedactBody, "", s)
+}
+
+func (r *redactor) json(body []byte) string {
+	if len(r.jsonFields) == 0 && len(r.jsonPaths) == 0 {
+		return string(body)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+	v = r.walk(v, nil)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+func (r *redactor) walk(v interface{}, path []string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			childPath := append(path[:len(path):len(path)], k)
+			if r.jsonFields[k] || r.matchPath(childPath) {
+				t[k] = redacted
+				continue
+			}
+			t[k] = r.walk(child, childPath)
+		}
+	case []interface{}:
+		for i, child := range t {
+			t[i] = r.walk(child, append(path[:len(path):len(path)], "*"))
+		}
+	}
+	return v
+}
+
+func (r *redactor) matchPath(path []string) bool {
+	for _, p := range r.jsonPaths {
+		if len(p) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range p {
+			if p[i] != "*" && p[i] != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
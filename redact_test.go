@@ -0,0 +1,108 @@
+package cast
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestRedact_Dump(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user":{"name":"cast","token":"secret"}}`))
+	}))
+	defer ts.Close()
+
+	logger := &captureLogger{}
+	c, err := New(
+		WithBaseURL(ts.URL),
+		WithLogger(logger),
+		WithDump(DumpStd),
+		WithRedactHeaders("X-Secret"),
+		WithRedactQueryParams("api_key"),
+		WithRedactJSONFields("password", "user.token"),
+		WithRedactor(func(kind, name, value string) string {
+			return strings.ReplaceAll(value, "cast", "c***")
+		}),
+	)
+	ok(t, err)
+
+	request := c.NewRequest().Post().WithPath("/?api_key=secret&page=1").
+		SetHeader("Authorization", "Bearer secret", "X-Secret", "secret").
+		WithJSONBody(map[string]interface{}{"name": "cast", "password": "secret"})
+	_, err = c.Do(context.TODO(), request)
+	ok(t, err)
+
+	assert(t, len(logger.entries) == 1, "expected a dump")
+	fields := logger.entries[0].fields
+	for k, v := range fields {
+		assert(t, !strings.Contains(toString(v), "secret"), "%s leaks a secret: %v", k, v)
+	}
+	requestHeaders := fields["request_headers"].(http.Header)
+	assert(t, requestHeaders.Get("Authorization") == redacted, "unexpected %v", requestHeaders.Get("Authorization"))
+	assert(t, requestHeaders.Get("X-Secret") == redacted, "unexpected %v", requestHeaders.Get("X-Secret"))
+	responseHeaders := fields["response_headers"].(http.Header)
+	assert(t, responseHeaders.Get("Set-Cookie") == redacted, "unexpected %v", responseHeaders.Get("Set-Cookie"))
+	assert(t, fields["request_body"] == `{"name":"c***","password":"[REDACTED]"}`, "unexpected %v", fields["request_body"])
+	assert(t, fields["response_body"] == `{"user":{"name":"c***","token":"[REDACTED]"}}`, "unexpected %v", fields["response_body"])
+	assert(t, strings.Contains(fields["url"].(string), "page=1"), "unexpected url %v", fields["url"])
+}
+
+func TestRedact_JSONPath(t *testing.T) {
+	r := newRedactor()
+	r.addJSONFields("items.*.secret")
+	body := r.json([]byte(`{"items":[{"secret":1,"id":2}],"secret":3}`))
+	assert(t, body == `{"items":[{"id":2,"secret":"[REDACTED]"}],"secret":3}`, "unexpected %v", body)
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case http.Header:
+		var b strings.Builder
+		_ = t.Write(&b)
+		return b.String()
+	}
+	return ""
+}
+
+func TestRedact_ErrorURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	baseURL := ts.URL
+	ts.Close()
+
+	logger := &captureLogger{}
+	tracer := mocktracer.New()
+	c, err := New(WithBaseURL(baseURL), WithLogger(logger), WithTracer(NewOpentracingTracer(tracer)),
+		WithRedactQueryParams("api_key"))
+	ok(t, err)
+
+	parent := tracer.StartSpan("parent")
+	_, err = c.Do(opentracing.ContextWithSpan(context.TODO(), parent), c.NewRequest().WithPath("/?api_key=secret"))
+	assert(t, err != nil, "expected an error")
+
+	assert(t, len(logger.entries) > 0, "expected the error to be logged")
+	for _, entry := range logger.entries {
+		for k, v := range entry.fields {
+			assert(t, !strings.Contains(fmt.Sprint(v), "secret"), "%s leaks a secret: %v", k, v)
+		}
+	}
+	for _, span := range tracer.FinishedSpans() {
+		for k, v := range span.Tags() {
+			assert(t, !strings.Contains(fmt.Sprint(v), "secret"), "%s leaks a secret: %v", k, v)
+		}
+		for _, record := range span.Logs() {
+			for _, f := range record.Fields {
+				assert(t, !strings.Contains(f.ValueString, "secret"), "%s leaks a secret: %v", f.Key, f.ValueString)
+			}
+		}
+	}
+}
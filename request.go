@@ -1,6 +1,10 @@
 package cast
 
 import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"time"
 )
@@ -27,21 +31,49 @@ type profiling struct {
 	receivingSart     time.Time
 	receivingDone     time.Time
 	receivingCost     time.Duration
+	connReused        bool
+	connWasIdle       bool
+	connIdleTime      time.Duration
 }
 
 // Request is the http.Request wrapper with attributes.
 type Request struct {
-	path          string
-	method        string
-	header        http.Header
-	queryParam    interface{}
-	pathParam     map[string]interface{}
-	body          requestBody
-	timeout       time.Duration
-	remoteAddress string
-	prof          profiling
-	rawRequest    *http.Request
-	circuitName   string
+	path             string
+	method           string
+	header           http.Header
+	queryParam       interface{}
+	pathParam        map[string]interface{}
+	body             requestBody
+	timeout          time.Duration
+	remoteAddress    string
+	prof             profiling
+	rawRequest       *http.Request
+	circuitName      string
+	stream           bool
+	retry            int
+	overrideRetry    bool
+	retryStatusCodes map[int]bool
+	idempotent       bool
+	hedgeDelay       time.Duration
+	hedgeMaxExtra    int
+	hedgeWinner      int
+	untracedCtx      context.Context
+	traceCtx         context.Context
+	pathTemplate     string
+	attempts         int
+	timings          []Timing
+	start            time.Time
+	dumpFlag         DumpFlag
+	overrideDump     bool
+	overrideDumpBody bool
+	dumpBodyLimit    int
+	expectSuccess    bool
+	typedCall        bool
+	errorBody        interface{}
+	codecs           codecRegistry
+	compression      string
+	compressedSize   int
+	signer           Signer
 }
 
 // NewRequest returns an instance of of Request.
@@ -50,9 +82,6 @@ func NewRequest() *Request {
 		method:    http.MethodGet,
 		header:    make(http.Header),
 		pathParam: make(map[string]interface{}),
-		prof: profiling{
-			requestStart: time.Now().In(time.UTC),
-		},
 	}
 }
 
@@ -60,6 +89,7 @@ func NewRequest() *Request {
 // if the base url don't be provided.
 func (r *Request) WithPath(path string) *Request {
 	r.path = path
+	r.pathTemplate = path
 	return r
 }
 
@@ -151,6 +181,15 @@ func (r *Request) WithXMLBody(body interface{}) *Request {
 	return r
 }
 
+// WithBody creates body encoded by the codec registered for contentType, see WithCodec.
+func (r *Request) WithBody(contentType string, body interface{}) *Request {
+	r.body = &requestCodecBody{
+		payload:     body,
+		contentType: contentType,
+	}
+	return r
+}
+
 // WithPlainBody creates body with plain text.
 func (r *Request) WithPlainBody(body string) *Request {
 	r.body = &requestPlainBody{
@@ -184,6 +223,26 @@ func (r *Request) WithCustomBody(contentType string, body []byte) *Request {
 	return r
 }
 
+// WithReaderBody creates body streamed from r.
+// The body can be sent again on retry only if r is an io.Seeker, which is left open for the caller to close.
+func (r *Request) WithReaderBody(contentType string, body io.Reader) *Request {
+	r.body = &requestReaderBody{
+		reader:      body,
+		contentType: contentType,
+	}
+	return r
+}
+
+// WithReaderBodyFunc creates body streamed from the reader returned by getBody,
+// which is called again to reopen the body on retry.
+func (r *Request) WithReaderBodyFunc(contentType string, getBody func() (io.ReadCloser, error)) *Request {
+	r.body = &requestReaderBody{
+		getBody:     getBody,
+		contentType: contentType,
+	}
+	return r
+}
+
 // WithTimeout sets the request timeout.
 func (r *Request) WithTimeout(timeout time.Duration) *Request {
 	r.timeout = timeout
@@ -230,6 +289,48 @@ func (r *Request) ReqBody() ([]byte, error) {
 	return body, nil
 }
 
+// bodyReader returns a fresh reader of the underlying body, compressed if WithCompressedBody is set.
+func (r *Request) bodyReader() (io.Reader, error) {
+	if b, ok := r.body.(streamRequestBody); ok {
+		rc, err := b.Reader()
+		if err != nil || len(r.compression) == 0 {
+			return rc, err
+		}
+		return compressStream(r.compression, rc)
+	}
+	body, err := r.ReqBody()
+	if err != nil {
+		return nil, err
+	}
+	if r.body != nil && len(r.compression) > 0 {
+		body, err = compress(r.compression, body)
+		if err != nil {
+			return nil, err
+		}
+		r.compressedSize = len(body)
+	}
+	return bytes.NewReader(body), nil
+}
+
+// rewindBody resets the body of the raw request before it is sent again.
+func (r *Request) rewindBody() error {
+	body, err := r.bodyReader()
+	if err != nil {
+		return err
+	}
+	rc, ok := body.(io.ReadCloser)
+	if !ok {
+		rc = ioutil.NopCloser(body)
+	}
+	r.rawRequest.Body = rc
+	return nil
+}
+
+// elapsed returns the time since Do started.
+func (r *Request) elapsed() time.Duration {
+	return time.Since(r.start)
+}
+
 // HeaderExist whether specified header exists.
 func (r *Request) HeaderExist(h string) bool {
 	if r == nil {
@@ -245,6 +346,88 @@ func (r *Request) WithCircuit(name string) *Request {
 	return r
 }
 
+// WithStreamResponse leaves the response body unread, it is exposed by Response.Stream
+// and must be closed by the caller.
+// Retries only happen before the body is handed back, the http client timeout does not apply
+// and the dump hook prints the headers only.
+func (r *Request) WithStreamResponse() *Request {
+	r.stream = true
+	return r
+}
+
+// WithRetry overrides the number of retries of cast for this request.
+func (r *Request) WithRetry(retry int) *Request {
+	r.retry = retry
+	r.overrideRetry = true
+	return r
+}
+
+// WithRetryStatusCodes overrides the status codes of cast that are retried for this request.
+func (r *Request) WithRetryStatusCodes(codes ...int) *Request {
+	r.retryStatusCodes = make(map[int]bool, len(codes))
+	for _, code := range codes {
+		r.retryStatusCodes[code] = true
+	}
+	return r
+}
+
+// Idempotent marks the request as safe to retry even if its method is not idempotent.
+func (r *Request) Idempotent() *Request {
+	r.idempotent = true
+	return r
+}
+
+// WithHedging fires up to maxExtra parallel attempts, one every delay while no response has arrived,
+// and keeps the first response. It only applies to idempotent requests,
+// and not to a body streamed from a reader unless it is set by WithReaderBodyFunc.
+// The hedged attempts count as a single execution of the circuit.
+func (r *Request) WithHedging(delay time.Duration, maxExtra int) *Request {
+	r.hedgeDelay = delay
+	r.hedgeMaxExtra = maxExtra
+	return r
+}
+
+// WithDump overrides the dump flags of cast for this request, no flag disables the dump.
+func (r *Request) WithDump(flags ...DumpFlag) *Request {
+	r.dumpFlag = dumpFlags(flags)
+	r.overrideDump = true
+	return r
+}
+
+// WithDumpBodyLimit overrides the dump body limit of cast for this request, a negative limit means no limit.
+func (r *Request) WithDumpBodyLimit(limit int) *Request {
+	r.dumpBodyLimit = limit
+	r.overrideDumpBody = true
+	return r
+}
+
+// WithCompressedBody compresses the body with encoding, one of EncodingGzip, EncodingDeflate,
+// EncodingBrotli or EncodingZstd, and sets the Content-Encoding header.
+func (r *Request) WithCompressedBody(encoding string) *Request {
+	r.compression = encoding
+	return r
+}
+
+// WithSigner overrides the signer of cast for this request.
+func (r *Request) WithSigner(signer Signer) *Request {
+	r.signer = signer
+	return r
+}
+
+// ExpectSuccess makes Do return an *HTTPError for a non-2xx response.
+func (r *Request) ExpectSuccess() *Request {
+	r.expectSuccess = true
+	return r
+}
+
+// WithErrorBody makes Do return an *HTTPError for a non-2xx response,
+// with the error body decoded into v.
+func (r *Request) WithErrorBody(v interface{}) *Request {
+	r.expectSuccess = true
+	r.errorBody = v
+	return r
+}
+
 // RawRequest returns the http request.
 func (r *Request) RawRequest() *http.Request {
 	return r.rawRequest
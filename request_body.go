@@ -4,13 +4,12 @@ import (
 	"encoding/json"
 	"encoding/xml"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"os"
 
 	"path/filepath"
 
-	"bytes"
-
 	"github.com/google/go-querystring/query"
 )
 
@@ -19,6 +18,13 @@ type requestBody interface {
 	Body() ([]byte, error)
 }
 
+// streamRequestBody is sent from a reader instead of being held in memory.
+// Reader returns a fresh reader on every call, or ErrBodyNotRewindable if it can't.
+type streamRequestBody interface {
+	requestBody
+	Reader() (io.ReadCloser, error)
+}
+
 type requestCustomBody struct {
 	payload     []byte
 	contentType string
@@ -34,6 +40,7 @@ func (body *requestCustomBody) Body() ([]byte, error) {
 
 type requestJSONBody struct {
 	payload interface{}
+	codec   Codec
 }
 
 func (body *requestJSONBody) ContentType() string {
@@ -48,6 +55,9 @@ func (body *requestJSONBody) Body() ([]byte, error) {
 	if ok {
 		return a, nil
 	}
+	if body.codec != nil {
+		return body.codec.Marshal(body.payload)
+	}
 	return json.Marshal(body.payload)
 }
 
@@ -76,6 +86,7 @@ func (body *requestFormURLEncodedBody) Body() ([]byte, error) {
 
 type requestXMLBody struct {
 	payload interface{}
+	codec   Codec
 }
 
 func (body *requestXMLBody) Body() ([]byte, error) {
@@ -86,6 +97,9 @@ func (body *requestXMLBody) Body() ([]byte, error) {
 	if ok {
 		return a, nil
 	}
+	if body.codec != nil {
+		return body.codec.Marshal(body.payload)
+	}
 	return xml.Marshal(body.payload)
 }
 
@@ -93,6 +107,35 @@ func (body *requestXMLBody) ContentType() string {
 	return applicationXML
 }
 
+// requestCodecBody is encoded by the codec registered for its content type.
+type requestCodecBody struct {
+	payload     interface{}
+	contentType string
+	codec       Codec
+}
+
+func (body *requestCodecBody) ContentType() string {
+	return body.contentType
+}
+
+func (body *requestCodecBody) Body() ([]byte, error) {
+	if body.payload == nil {
+		return []byte{}, nil
+	}
+	a, ok := body.payload.([]byte)
+	if ok {
+		return a, nil
+	}
+	if body.codec == nil {
+		codec, err := defaultCodecs.lookup(body.contentType)
+		if err != nil {
+			return nil, err
+		}
+		body.codec = codec
+	}
+	return body.codec.Marshal(body.payload)
+}
+
 type requestPlainBody struct {
 	payload string
 }
@@ -115,23 +158,76 @@ type FormData struct {
 }
 
 type requestMultipartFormDataBody struct {
-	formData    []*FormData
-	contentType string
+	formData []*FormData
+	boundary string
+	used     bool
 }
 
 func (body *requestMultipartFormDataBody) ContentType() string {
-	return body.contentType
+	w := multipart.NewWriter(ioutil.Discard)
+	if body.boundary == "" {
+		body.boundary = w.Boundary()
+	}
+	_ = w.SetBoundary(body.boundary)
+	return w.FormDataContentType()
 }
 
 func (body *requestMultipartFormDataBody) Body() ([]byte, error) {
-	buffer := &bytes.Buffer{}
-	w := multipart.NewWriter(buffer)
+	reader, err := body.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// Reader writes the form through a pipe, files are opened only while being sent.
+func (body *requestMultipartFormDataBody) Reader() (io.ReadCloser, error) {
+	if body.used {
+		if err := body.rewind(); err != nil {
+			return nil, err
+		}
+	}
+	body.used = true
 
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	_ = body.ContentType()
+	if err := w.SetBoundary(body.boundary); err != nil {
+		return nil, err
+	}
+	go func() {
+		err := body.write(w)
+		if err == nil {
+			err = w.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+func (body *requestMultipartFormDataBody) rewind() error {
+	for _, data := range body.formData {
+		if data.Value != "" || len(data.Path) > 0 || data.Reader == nil {
+			continue
+		}
+		seeker, ok := data.Reader.(io.Seeker)
+		if !ok {
+			return ErrBodyNotRewindable
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (body *requestMultipartFormDataBody) write(w *multipart.Writer) error {
 	for _, data := range body.formData {
 		switch {
 		case data.Value != "":
 			if err := w.WriteField(data.FieldName, data.Value); err != nil {
-				return nil, err
+				return err
 			}
 		default:
 			if data.FieldName == "" || data.FileName == "" {
@@ -144,37 +240,80 @@ func (body *requestMultipartFormDataBody) Body() ([]byte, error) {
 
 			fw, err := w.CreateFormFile(data.FieldName, data.FileName)
 			if err != nil {
-				return nil, err
+				return err
 			}
 
 			switch {
 			case len(data.Path) > 0:
-				path, err := filepath.Abs(data.Path)
-				if err != nil {
-					return nil, err
+				if err := copyFile(fw, data.Path); err != nil {
+					return err
 				}
-				f, err := os.Open(path)
-				if err != nil {
-					return nil, err
-				}
-				defer f.Close()
-				_, err = io.Copy(fw, f)
-				if err != nil {
-					return nil, err
-				}
-
 			case data.Reader != nil:
 				_, err := io.Copy(fw, data.Reader)
 				if err != nil {
-					return nil, err
+					return err
 				}
 			}
 		}
 	}
-	err := w.Close()
+	return nil
+}
+
+func copyFile(w io.Writer, name string) error {
+	path, err := filepath.Abs(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(path)
 	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+type requestReaderBody struct {
+	reader      io.Reader
+	getBody     func() (io.ReadCloser, error)
+	contentType string
+	used        bool
+}
+
+func (body *requestReaderBody) ContentType() string {
+	return body.contentType
+}
+
+func (body *requestReaderBody) Body() ([]byte, error) {
+	reader, err := body.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// Reader returns the reader itself the first time, afterwards the body is reopened
+// by getBody or seeked to the start if the reader is an io.Seeker.
+// A seeker is never handed over as an io.ReadCloser, as the transport closes the body
+// once sent, which would prevent sending it again.
+func (body *requestReaderBody) Reader() (io.ReadCloser, error) {
+	if body.getBody != nil {
+		return body.getBody()
+	}
+	seeker, seekable := body.reader.(io.Seeker)
+	switch {
+	case !body.used:
+		body.used = true
+		if rc, ok := body.reader.(io.ReadCloser); ok && !seekable {
+			return rc, nil
+		}
+		return ioutil.NopCloser(body.reader), nil
+	case !seekable:
+		return nil, ErrBodyNotRewindable
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
 		return nil, err
 	}
-	body.contentType = w.FormDataContentType()
-	return buffer.Bytes(), nil
+	return ioutil.NopCloser(body.reader), nil
 }
@@ -2,10 +2,16 @@ package cast
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-querystring/query"
 )
@@ -148,3 +154,82 @@ func TestReqMultipartFormDataBody(t *testing.T) {
 
 	assert(t, strings.HasPrefix(multipartBody.ContentType(), "multipart/form-data; boundary="), "unexpected multipart ContentType()")
 }
+
+func TestReqMultipartFormDataBody_Stream(t *testing.T) {
+	f, err := ioutil.TempFile("", "cast")
+	ok(t, err)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("file body")
+	ok(t, err)
+	ok(t, f.Close())
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("media")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		data, _ := ioutil.ReadAll(file)
+		_, _ = w.Write(data)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	request := c.NewRequest().Post().WithPath("/").WithMultipartFormDataBody(
+		&FormData{FieldName: "text", Value: "input"},
+		&FormData{FieldName: "media", FileName: "test.txt", Path: f.Name()},
+	)
+	resp, err := c.Do(context.TODO(), request)
+	ok(t, err)
+	assert(t, resp.String() == "file body", "unexpected response: %s", resp.String())
+}
+
+func TestReqReaderBody_Rewind(t *testing.T) {
+	seekable := requestReaderBody{reader: strings.NewReader("abc")}
+	for i := 0; i < 2; i++ {
+		body, err := seekable.Body()
+		ok(t, err)
+		assert(t, string(body) == "abc", "%d: unexpected Body()", i)
+	}
+
+	oneShot := requestReaderBody{reader: bytes.NewBufferString("abc")}
+	_, err := oneShot.Body()
+	ok(t, err)
+	_, err = oneShot.Body()
+	assert(t, err == ErrBodyNotRewindable, "expected ErrBodyNotRewindable, got %v", err)
+}
+
+func TestReqReaderBody_RetryFile(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		calls++
+		if calls == 1 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				_ = conn.Close()
+			}
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	f, err := ioutil.TempFile("", "cast")
+	ok(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	_, err = f.WriteString("file body")
+	ok(t, err)
+	_, err = f.Seek(0, 0)
+	ok(t, err)
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(1), WithConstantBackoffStrategy(time.Millisecond))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().Put().WithReaderBody("text/plain", f))
+	ok(t, err)
+	assert(t, calls == 2, "unexpected calls %d", calls)
+	assert(t, resp.String() == "file body", "unexpected response: %s", resp.String())
+}
@@ -27,13 +27,13 @@ var defaultRequestHooks = []RequestHook{
 func finalizeQueryParamIfAny(cast *Cast, request *Request) error {
 	values, err := url.ParseQuery(request.rawRequest.URL.RawQuery)
 	if err != nil {
-		cast.Logger().WithError(err).Error("url.ParseQuery")
+		cast.logger.Error("url.ParseQuery", cast.errField(err))
 		return err
 	}
 
 	qValues, err := query.Values(request.queryParam)
 	if err != nil {
-		cast.Logger().WithError(err).Error(" query.Values")
+		cast.logger.Error("query.Values", cast.errField(err))
 		return err
 	}
 	for k, vv := range qValues {
@@ -91,43 +91,56 @@ func setTimeoutIfAny(_ *Cast, request *Request) error {
 }
 
 func clientTrace(_ *Cast, request *Request) error {
-	trace := &httptrace.ClientTrace{
+	request.untracedCtx = request.rawRequest.Context()
+	trace := newClientTrace(&request.prof, &request.remoteAddress)
+	request.rawRequest = request.rawRequest.WithContext(httptrace.WithClientTrace(request.rawRequest.Context(), trace))
+	return nil
+}
+
+func newClientTrace(prof *profiling, remoteAddress *string) *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
 		GotFirstResponseByte: func() {
-			request.prof.waitingDone = time.Now().In(time.UTC)
-			request.prof.waitingCost = request.prof.waitingDone.Sub(request.prof.waitingStart)
-			request.prof.receivingSart = time.Now().In(time.UTC)
+			prof.waitingDone = time.Now().In(time.UTC)
+			prof.waitingCost = prof.waitingDone.Sub(prof.waitingStart)
+			prof.receivingSart = time.Now().In(time.UTC)
 		},
 		DNSStart: func(httptrace.DNSStartInfo) {
-			request.prof.dnsStart = time.Now().In(time.UTC)
+			prof.dnsStart = time.Now().In(time.UTC)
 		},
 		DNSDone: func(httptrace.DNSDoneInfo) {
-			request.prof.dnsDone = time.Now().In(time.UTC)
-			request.prof.dnsCost = request.prof.dnsDone.Sub(request.prof.dnsStart)
+			prof.dnsDone = time.Now().In(time.UTC)
+			prof.dnsCost = prof.dnsDone.Sub(prof.dnsStart)
 		},
 		ConnectStart: func(network, addr string) {
-			request.prof.connectStart = time.Now().In(time.UTC)
+			prof.connectStart = time.Now().In(time.UTC)
 		},
 		ConnectDone: func(network, addr string, err error) {
-			request.prof.connectDone = time.Now().In(time.UTC)
-			request.prof.connectCost = request.prof.connectDone.Sub(request.prof.connectStart)
-			request.remoteAddress = addr
+			prof.connectDone = time.Now().In(time.UTC)
+			prof.connectCost = prof.connectDone.Sub(prof.connectStart)
+			*remoteAddress = addr
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			prof.connReused = info.Reused
+			prof.connWasIdle = info.WasIdle
+			prof.connIdleTime = info.IdleTime
+			if info.Conn != nil {
+				*remoteAddress = info.Conn.RemoteAddr().String()
+			}
 		},
 		TLSHandshakeStart: func() {
-			request.prof.tlsHandshakeStart = time.Now().In(time.UTC)
+			prof.tlsHandshakeStart = time.Now().In(time.UTC)
 		},
 		TLSHandshakeDone: func(tls.ConnectionState, error) {
-			request.prof.tlsHandshakeDone = time.Now().In(time.UTC)
-			request.prof.tlsHandshakeCost = request.prof.tlsHandshakeDone.Sub(request.prof.tlsHandshakeStart)
+			prof.tlsHandshakeDone = time.Now().In(time.UTC)
+			prof.tlsHandshakeCost = prof.tlsHandshakeDone.Sub(prof.tlsHandshakeStart)
 		},
 		WroteHeaders: func() {
-			request.prof.sendingStart = time.Now().In(time.UTC)
+			prof.sendingStart = time.Now().In(time.UTC)
 		},
 		WroteRequest: func(httptrace.WroteRequestInfo) {
-			request.prof.sendingDone = time.Now().In(time.UTC)
-			request.prof.sendingCost = request.prof.sendingDone.Sub(request.prof.sendingStart)
-			request.prof.waitingStart = time.Now().In(time.UTC)
+			prof.sendingDone = time.Now().In(time.UTC)
+			prof.sendingCost = prof.sendingDone.Sub(prof.sendingStart)
+			prof.waitingStart = time.Now().In(time.UTC)
 		},
 	}
-	request.rawRequest = request.rawRequest.WithContext(httptrace.WithClientTrace(request.rawRequest.Context(), trace))
-	return nil
 }
@@ -3,15 +3,21 @@ package cast
 import (
 	"encoding/json"
 	"encoding/xml"
+	"io"
 	"net/http"
 )
 
 // Response wraps the raw response with attributes.
 type Response struct {
-	request     *Request
-	rawResponse *http.Response
-	statusCode  int
-	body        []byte
+	request       *Request
+	rawResponse   *http.Response
+	statusCode    int
+	body          []byte
+	stream        io.ReadCloser
+	fromCache     bool
+	hedgedAttempt int
+	// compressedSize is the size of the body on the wire, 0 if it was not compressed.
+	compressedSize int
 }
 
 // StatusCode returns http status code.
@@ -32,6 +38,45 @@ func (resp *Response) Body() []byte {
 	return resp.body
 }
 
+// Stream returns the unread response body of a request made with WithStreamResponse, otherwise nil.
+// The caller must close it.
+func (resp *Response) Stream() io.ReadCloser {
+	return resp.stream
+}
+
+// IsStream returns true if the response body is streamed.
+func (resp *Response) IsStream() bool {
+	return resp.stream != nil
+}
+
+// Close closes the underlying stream if any.
+func (resp *Response) Close() error {
+	if resp.stream == nil {
+		return nil
+	}
+	return resp.stream.Close()
+}
+
+// FromCache returns true if the response is served from the cache.
+func (resp *Response) FromCache() bool {
+	return resp.fromCache
+}
+
+// HedgedAttempt returns which attempt of a hedged request won, 0 being the original one.
+func (resp *Response) HedgedAttempt() int {
+	return resp.hedgedAttempt
+}
+
+// Timings returns the timings of every attempt, nil if the response is served from the cache.
+func (resp *Response) Timings() []Timing {
+	if resp.request == nil || resp.fromCache {
+		return nil
+	}
+	timings := make([]Timing, len(resp.request.timings))
+	copy(timings, resp.request.timings)
+	return timings
+}
+
 // String returns the underlying body in string.
 func (resp *Response) String() string {
 	return string(resp.body)
@@ -53,6 +98,20 @@ func (resp *Response) DecodeFromXML(v interface{}) error {
 	return xml.Unmarshal(resp.body, &v)
 }
 
+// Decode decodes the body into v with the codec registered for the response Content-Type,
+// JSON if there is none.
+func (resp *Response) Decode(v interface{}) error {
+	codecs := defaultCodecs
+	if resp.request != nil && resp.request.codecs != nil {
+		codecs = resp.request.codecs
+	}
+	codec, err := codecs.lookup(resp.Header().Get(contentType))
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(resp.body, v)
+}
+
 // Size returns the length of the body.
 func (resp *Response) Size() int64 {
 	if resp.rawResponse == nil {
@@ -1,9 +1,6 @@
 package cast
 
-import (
-	"bytes"
-	"fmt"
-)
+import "strconv"
 
 type responseHook func(c *Cast, response *Response) error
 
@@ -12,106 +9,79 @@ var defaultResponseHooks = []responseHook{
 }
 
 func dump(cast *Cast, response *Response) error {
-	buffer := getBuffer()
-	defer putBuffer(buffer)
-
-	var err error
-	prt := func(buffer *bytes.Buffer, format string, a ...interface{}) {
-		if err != nil {
-			return
-		}
-		_, err = fmt.Fprintf(buffer, format, a...)
+	request := response.request
+	flag, limit := cast.dumpFlag, cast.dumpBodyLimit
+	if request.overrideDump {
+		flag = request.dumpFlag
 	}
-
-	shouldPrintHeaders := cast.dumpFlag&fHeader != 0
-	if shouldPrintHeaders {
-		prt(buffer, "\nHeaders\n")
-		prt(buffer, "Request URL: %s\n", response.request.rawRequest.URL.String())
-		prt(buffer, "Request Method: %s\n", response.request.method)
-		prt(buffer, "Remote Address: %s\n", response.request.remoteAddress)
-		prt(buffer, "Status Code: %s\n", response.rawResponse.Status)
-		prt(buffer, "Version: %s\n", response.rawResponse.Proto)
-		prt(buffer, "Response Headers\n")
-		if err != nil {
-			return err
-		}
-
-		err = response.rawResponse.Header.Write(buffer)
-		if err != nil {
-			return err
-		}
-
-		prt(buffer, "Request Headers\n")
-		if err != nil {
-			return err
-		}
-
-		err = response.request.rawRequest.Header.Write(buffer)
-		if err != nil {
-			return err
-		}
-
-		prt(buffer, "\n")
-		if err != nil {
-			return err
-		}
+	if request.overrideDumpBody {
+		limit = request.dumpBodyLimit
+	}
+	if flag&^DumpOnError == 0 {
+		return nil
+	}
+	if flag&DumpOnError != 0 && response.Success() {
+		return nil
 	}
 
-	shouldPrintParams := cast.dumpFlag&fParam != 0
-	if shouldPrintParams && len(response.body) <= defaultDumpBodyLimit {
-		prt(buffer, "Params\n")
-		if err != nil {
-			return err
-		}
-
-		if response.request.body != nil {
-			body, _ := response.request.body.Body()
-			prt(buffer, string(body))
-		}
-
-		prt(buffer, "\n")
-		if err != nil {
-			return err
-		}
-
+	fields := append(cast.logFields(request), field("status", response.statusCode))
+	if response.fromCache {
+		fields = append(fields, field("from_cache", true))
+	}
+	if request.hedgeDelay > 0 {
+		fields = append(fields, field("hedged_attempt", response.hedgedAttempt))
 	}
 
-	shouldPrintResponse := cast.dumpFlag&fResponse != 0
-	if shouldPrintResponse && len(response.body) <= defaultDumpBodyLimit {
-		prt(buffer, "Response\n")
-		prt(buffer, string(response.body))
-		prt(buffer, "\n\n")
-		if err != nil {
-			return err
-		}
+	if flag&DumpHeader != 0 {
+		fields = append(fields,
+			field("remote_address", request.remoteAddress),
+			field("proto", response.rawResponse.Proto),
+			field("response_headers", cast.redactor.header(response.rawResponse.Header)),
+			field("request_headers", cast.redactor.header(request.rawRequest.Header)),
+		)
 	}
 
-	shouldPrintTimings := cast.dumpFlag&fTiming != 0
-	if shouldPrintTimings {
-		prt(buffer, "Timings\n")
-		prt(buffer, "DNS resolution: %s\n", response.request.prof.dnsCost)
-		prt(buffer, "Connecting: %s\n", response.request.prof.connectCost)
-		if err != nil {
-			return err
-		}
-		if response.request.prof.tlsHandshakeCost.Nanoseconds() > 0 {
-			prt(buffer, "TLS setup: %s\n", response.request.prof.tlsHandshakeCost)
-			if err != nil {
-				return err
+	if !response.IsStream() {
+		if flag&DumpParam != 0 {
+			if _, ok := request.body.(streamRequestBody); ok {
+				fields = append(fields, field("request_body", "(stream)"))
+			} else if request.body != nil {
+				body, _ := request.body.Body()
+				fields = append(fields, field("request_body", truncate(cast.redactor.body(request.body.ContentType(), body), limit)))
+				if request.compressedSize > 0 {
+					fields = append(fields, field("request_size", len(body)), field("request_compressed_size", request.compressedSize))
+				}
 			}
 		}
-		prt(buffer, "Sending: %s\n", response.request.prof.sendingCost.String())
-		prt(buffer, "Waiting: %s\n", response.request.prof.waitingCost.String())
-		prt(buffer, "Receiving: %s\n", response.request.prof.receivingCost.String())
-		prt(buffer, "All: %s\n", response.request.prof.requestCost.String())
-		if err != nil {
-			return err
+
+		if flag&DumpResponse != 0 {
+			body := cast.redactor.body(response.Header().Get(contentType), response.body)
+			fields = append(fields, field("response_body", truncate(body, limit)))
+			if response.compressedSize > 0 {
+				fields = append(fields, field("response_size", len(response.body)), field("response_compressed_size", response.compressedSize))
+			}
 		}
 	}
 
-	if buffer.Len() > 0 {
-		cast.Logger().Info(buffer.String())
+	if flag&DumpTiming != 0 && !response.fromCache {
+		fields = append(fields,
+			field("dns", request.prof.dnsCost),
+			field("connect", request.prof.connectCost),
+			field("tls", request.prof.tlsHandshakeCost),
+			field("sending", request.prof.sendingCost),
+			field("waiting", request.prof.waitingCost),
+			field("receiving", request.prof.receivingCost),
+		)
 	}
 
+	cast.logger.Info("cast: request done", fields...)
 	return nil
 }
+
+// truncate returns at most limit bytes of body, a negative limit means no limit.
+func truncate(body string, limit int) string {
+	if limit < 0 || len(body) <= limit {
+		return body
+	}
+	return body[:limit] + "...(truncated, " + strconv.Itoa(len(body)) + " bytes)"
+}
@@ -1 +1,114 @@
 package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type logEntry struct {
+	msg    string
+	fields map[string]interface{}
+}
+
+type captureLogger struct {
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *captureLogger) log(msg string, fields []Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	m := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		m[f.Key] = f.Value
+	}
+	l.entries = append(l.entries, logEntry{msg: msg, fields: m})
+}
+
+func (l *captureLogger) Debug(msg string, fields ...Field) { l.log(msg, fields) }
+
+func (l *captureLogger) Info(msg string, fields ...Field) { l.log(msg, fields) }
+
+func (l *captureLogger) Warn(msg string, fields ...Field) { l.log(msg, fields) }
+
+func (l *captureLogger) Error(msg string, fields ...Field) { l.log(msg, fields) }
+
+func TestDump_Flags(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = w.Write([]byte(strings.Repeat("a", 20)))
+	}))
+	defer ts.Close()
+
+	logger := &captureLogger{}
+	c, err := New(WithBaseURL(ts.URL), WithLogger(logger), WithDump(DumpResponse, DumpOnError), WithDumpBodyLimit(5))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	ok(t, err)
+	assert(t, len(logger.entries) == 0, "expected no dump for a 2xx response")
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/missing"))
+	ok(t, err)
+	assert(t, len(logger.entries) == 1, "expected a dump for a 404 response")
+	fields := logger.entries[0].fields
+	assert(t, fields["response_body"] == "aaaaa...(truncated, 20 bytes)", "unexpected response body %v", fields["response_body"])
+	_, hasHeaders := fields["request_headers"]
+	assert(t, !hasHeaders, "unexpected headers")
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/").WithDump(DumpHeader))
+	ok(t, err)
+	assert(t, len(logger.entries) == 2, "expected a dump overridden by the request")
+	_, hasHeaders = logger.entries[1].fields["request_headers"]
+	assert(t, hasHeaders, "expected headers")
+}
+
+func TestDump_RequestBodyLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("a", 20)))
+	}))
+	defer ts.Close()
+
+	logger := &captureLogger{}
+	c, err := New(WithBaseURL(ts.URL), WithLogger(logger), WithDump(DumpResponse), WithDumpBodyLimit(5))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithDumpBodyLimit(-1))
+	ok(t, err)
+	body := logger.entries[0].fields["response_body"]
+	assert(t, body == strings.Repeat("a", 20), "unexpected response body %v", body)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithDumpBodyLimit(0))
+	ok(t, err)
+	body = logger.entries[1].fields["response_body"]
+	assert(t, body == "...(truncated, 20 bytes)", "unexpected response body %v", body)
+}
+
+func TestDump_OnErrorOnly(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	logger := &captureLogger{}
+	c, err := New(WithBaseURL(ts.URL), WithLogger(logger), WithDump(DumpOnError))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/"))
+	ok(t, err)
+	assert(t, len(logger.entries) == 0, "expected no dump for a 2xx response")
+
+	_, err = c.Do(context.TODO(), c.NewRequest().WithPath("/missing"))
+	ok(t, err)
+	assert(t, len(logger.entries) == 1, "expected a dump for a 404 response")
+	_, hasHeaders := logger.entries[0].fields["request_headers"]
+	assert(t, hasHeaders, "expected DumpOnError alone to dump everything")
+}
@@ -0,0 +1,72 @@
+package cast
+
+import (
+	"sync"
+	"time"
+)
+
+const retryBudgetBuckets = 10
+
+type retryBucket struct {
+	start    time.Time
+	requests int
+	retries  int
+}
+
+// retryBudget allows retries as long as they stay below a ratio of the requests
+// made in a sliding window, plus a minimum number of retries per window.
+type retryBudget struct {
+	mu          sync.Mutex
+	ratio       float64
+	minRetries  int
+	window      time.Duration
+	bucketWidth time.Duration
+	buckets     [retryBudgetBuckets]retryBucket
+}
+
+func newRetryBudget(ratio float64, window time.Duration, minRetries int) *retryBudget {
+	width := window / retryBudgetBuckets
+	if width <= 0 {
+		width = 1
+	}
+	return &retryBudget{
+		ratio:       ratio,
+		minRetries:  minRetries,
+		window:      window,
+		bucketWidth: width,
+	}
+}
+
+func (b *retryBudget) bucket(now time.Time) *retryBucket {
+	start := now.Truncate(b.bucketWidth)
+	bucket := &b.buckets[(start.UnixNano()/int64(b.bucketWidth))%retryBudgetBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = retryBucket{start: start}
+	}
+	return bucket
+}
+
+func (b *retryBudget) recordRequest() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.bucket(time.Now()).requests++
+}
+
+// withdraw records a retry and returns true if the budget allows it.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	var requests, retries int
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.window {
+			requests += bucket.requests
+			retries += bucket.retries
+		}
+	}
+	if retries >= b.minRetries && float64(retries+1) > b.ratio*float64(requests) {
+		return false
+	}
+	b.bucket(now).retries++
+	return true
+}
@@ -0,0 +1,80 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget_Withdraw(t *testing.T) {
+	b := newRetryBudget(0.1, time.Minute, 1)
+	b.recordRequest()
+	assert(t, b.withdraw(), "expected the minimum retry to be allowed")
+	assert(t, !b.withdraw(), "expected the budget to be exhausted")
+
+	for i := 0; i < 19; i++ {
+		b.recordRequest()
+	}
+	assert(t, b.withdraw(), "expected 10%% of 20 requests to allow 2 retries")
+	assert(t, !b.withdraw(), "expected the budget to be exhausted")
+}
+
+func TestCast_BackoffCancelled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(3), WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithConstantBackoffStrategy(time.Hour))
+	ok(t, err)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = c.Do(ctx, c.NewRequest())
+	assert(t, err == context.DeadlineExceeded, "unexpected error %v", err)
+	assert(t, time.Since(start) < time.Second, "expected backoff to be aborted")
+}
+
+func TestCast_MaxElapsedTime(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(10), WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithConstantBackoffStrategy(30*time.Millisecond), WithMaxElapsedTime(100*time.Millisecond))
+	ok(t, err)
+
+	resp, err := c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusServiceUnavailable, "unexpected status code")
+	assert(t, atomic.LoadInt32(&hits) < 5, "unexpected hits %d", hits)
+}
+
+func TestCast_MaxElapsedTimeDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(3), WithRetryStatusCodes(http.StatusServiceUnavailable),
+		WithMaxElapsedTime(100*time.Millisecond))
+	ok(t, err)
+
+	start := time.Now()
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	assert(t, errors.Is(err, context.DeadlineExceeded), "unexpected error %v", err)
+	assert(t, time.Since(start) < 500*time.Millisecond, "expected the attempt to be aborted")
+}
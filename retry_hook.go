@@ -1,5 +1,16 @@
 package cast
 
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	retryAfter           = "Retry-After"
+	defaultMaxRetryAfter = 30 * time.Second
+)
+
 // RetryHook defines a retry cond.
 type RetryHook func(response *Response, err error) bool
 
@@ -10,3 +21,65 @@ var defaultRetryHooks = []RetryHook{
 func retry(_ *Response, err error) bool {
 	return ShouldRetry(err)
 }
+
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+func (c *Cast) shouldRetry(request *Request, response *Response, err error) bool {
+	if c.retryIdempotentOnly && !request.idempotent && !idempotentMethods[request.rawRequest.Method] {
+		return false
+	}
+	for _, hook := range c.retryHooks {
+		if hook(response, err) {
+			return true
+		}
+	}
+	statusCodes := c.retryStatusCodes
+	if request.retryStatusCodes != nil {
+		statusCodes = request.retryStatusCodes
+	}
+	return err == nil && statusCodes[response.statusCode]
+}
+
+// retryDelay prefers the Retry-After header, capped at maxRetryAfter, to the backoff strategy.
+// It returns false if there is no way to tell how long to wait.
+func (c *Cast) retryDelay(attempt *BackoffAttempt) (time.Duration, bool) {
+	if d, ok := parseRetryAfter(attempt.Response.Header().Get(retryAfter)); ok {
+		if d > c.maxRetryAfter {
+			d = c.maxRetryAfter
+		}
+		return d, true
+	}
+	if c.stg != nil {
+		return c.stg.Backoff(attempt), true
+	}
+	return 0, false
+}
+
+// parseRetryAfter parses the delay in seconds or the http date.
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if len(v) == 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	t, err := http.ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	d := time.Until(t)
+	if d < 0 {
+		d = 0
+	}
+	return d, true
+}
@@ -1 +1,65 @@
 package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := [...]struct {
+		v    string
+		want time.Duration
+		ok   bool
+	}{
+		0: {v: "", ok: false},
+		1: {v: "3", want: 3 * time.Second, ok: true},
+		2: {v: "-1", ok: false},
+		3: {v: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0, ok: true},
+		4: {v: "soon", ok: false},
+	}
+
+	for i, tt := range tests {
+		d, ok := parseRetryAfter(tt.v)
+		assert(t, ok == tt.ok && d == tt.want, "%d: unexpected parseRetryAfter: %s, %t", i, d, ok)
+	}
+}
+
+func TestCast_RetryStatusCodes(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.Header().Set(retryAfter, "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(2), WithRetryStatusCodes(http.StatusServiceUnavailable), WithRetryIdempotentOnly())
+	ok(t, err)
+
+	resp, err := c.Do(context.TODO(), c.NewRequest().Get())
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusOK, "unexpected status code %d", resp.StatusCode())
+	assert(t, atomic.LoadInt32(&hits) == 2, "unexpected hits %d", hits)
+
+	atomic.StoreInt32(&hits, 0)
+	resp, err = c.Do(context.TODO(), c.NewRequest().Post())
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusServiceUnavailable, "expected POST not to be retried")
+
+	atomic.StoreInt32(&hits, 0)
+	resp, err = c.Do(context.TODO(), c.NewRequest().Post().Idempotent())
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusOK, "expected idempotent POST to be retried")
+
+	atomic.StoreInt32(&hits, 0)
+	resp, err = c.Do(context.TODO(), c.NewRequest().Get().WithRetry(0))
+	ok(t, err)
+	assert(t, resp.StatusCode() == http.StatusServiceUnavailable, "expected per request retry override")
+}
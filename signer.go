@@ -0,0 +1,120 @@
+package cast
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Signer signs a request once its method, url, headers and body are final.
+// body is the payload as sent on the wire, nil for a streamed body.
+// Sign is called before every attempt, right before it is sent.
+type Signer interface {
+	Sign(request *http.Request, body []byte) error
+}
+
+// SignerFunc adapts a function to a Signer.
+type SignerFunc func(request *http.Request, body []byte) error
+
+// Sign calls f.
+func (f SignerFunc) Sign(request *http.Request, body []byte) error {
+	return f(request, body)
+}
+
+func signRequest(cast *Cast, request *Request) error {
+	signer := cast.signer
+	if request.signer != nil {
+		signer = request.signer
+	}
+	if signer == nil {
+		return nil
+	}
+	var body []byte
+	if _, ok := request.body.(streamRequestBody); !ok && request.rawRequest.GetBody != nil {
+		rc, err := request.rawRequest.GetBody()
+		if err != nil {
+			return err
+		}
+		body, err = io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if err := signer.Sign(request.rawRequest, body); err != nil {
+		cast.logger.Error("signer.Sign", cast.errField(err))
+		return err
+	}
+	return nil
+}
+
+const (
+	defaultHMACHeader          = "X-Signature"
+	defaultHMACTimestampHeader = "X-Timestamp"
+)
+
+// HMACSigner signs requests with HMAC-SHA256.
+type HMACSigner struct {
+	Key []byte
+	// Header receives the signature, X-Signature if empty.
+	Header string
+	// Prefix is prepended to the signature, e.g. "sha256=".
+	Prefix string
+	// TimestampHeader receives the unix time of the signature, X-Timestamp if empty, none if "-".
+	TimestampHeader string
+	// Canonicalize returns the string to sign, HMACCanonical if nil.
+	Canonicalize func(request *http.Request, body []byte, timestamp string) string
+	// Encode encodes the signature, hex if nil.
+	Encode func(sum []byte) string
+	// Now returns the signing time, time.Now if nil.
+	Now func() time.Time
+}
+
+// HMACCanonical returns the method, escaped path, sorted query, timestamp and hex encoded
+// SHA-256 of the body, separated by new lines.
+func HMACCanonical(request *http.Request, body []byte, timestamp string) string {
+	sum := sha256.Sum256(body)
+	return request.Method + "\n" +
+		request.URL.EscapedPath() + "\n" +
+		request.URL.Query().Encode() + "\n" +
+		timestamp + "\n" +
+		hex.EncodeToString(sum[:])
+}
+
+// Sign implements Signer.
+func (s *HMACSigner) Sign(request *http.Request, body []byte) error {
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	timestampHeader := s.TimestampHeader
+	if len(timestampHeader) == 0 {
+		timestampHeader = defaultHMACTimestampHeader
+	}
+	if timestampHeader != "-" {
+		request.Header.Set(timestampHeader, timestamp)
+	}
+
+	canonicalize := s.Canonicalize
+	if canonicalize == nil {
+		canonicalize = HMACCanonical
+	}
+	mac := hmac.New(sha256.New, s.Key)
+	mac.Write([]byte(canonicalize(request, body, timestamp)))
+
+	encode := s.Encode
+	if encode == nil {
+		encode = hex.EncodeToString
+	}
+	header := s.Header
+	if len(header) == 0 {
+		header = defaultHMACHeader
+	}
+	request.Header.Set(header, s.Prefix+encode(mac.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,93 @@
+package cast
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAWSSigner(t *testing.T) {
+	// get-vanilla of the AWS Signature Version 4 test suite.
+	request, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	ok(t, err)
+	signer := &AWSSigner{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Region:          "us-east-1",
+		Service:         "service",
+		Now: func() time.Time {
+			return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+		},
+	}
+	ok(t, signer.Sign(request, nil))
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	assert(t, request.Header.Get("Authorization") == want, "unexpected authorization %s", request.Header.Get("Authorization"))
+}
+
+func TestHMACSigner_Retry(t *testing.T) {
+	key := []byte("secret")
+	var (
+		calls      int
+		timestamps []string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(HMACCanonical(r, body, r.Header.Get("X-Timestamp"))))
+		if r.Header.Get("X-Signature") != "sha256="+hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		timestamps = append(timestamps, r.Header.Get("X-Timestamp"))
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	now := time.Unix(1700000000, 0)
+	signer := &HMACSigner{
+		Key:    key,
+		Prefix: "sha256=",
+		Now: func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		},
+	}
+	c, err := New(WithBaseURL(ts.URL), WithSigner(signer), WithRetry(1),
+		WithRetryStatusCodes(http.StatusServiceUnavailable), WithConstantBackoffStrategy(0))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest().Post().WithPath("/orders?b=2&a=1").WithJSONBody(map[string]int{"id": 1}))
+	ok(t, err)
+	assert(t, resp.StatusOk(), "unexpected status code %d", resp.StatusCode())
+	assert(t, len(timestamps) == 2 && timestamps[0] != timestamps[1], "expected a fresh signature on retry, got %v", timestamps)
+}
+
+func TestSigner_AfterRateLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var signedAt []time.Time
+	signer := SignerFunc(func(r *http.Request, body []byte) error {
+		signedAt = append(signedAt, time.Now())
+		return nil
+	})
+	c, err := New(WithBaseURL(ts.URL), WithSigner(signer), WithRateLimit(10, 1))
+	ok(t, err)
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	start := time.Now()
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, len(signedAt) == 2, "unexpected signatures %d", len(signedAt))
+	assert(t, signedAt[1].Sub(start) >= 50*time.Millisecond, "expected the request to be signed after the rate limit wait")
+}
@@ -0,0 +1,220 @@
+package cast
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cep21/circuit/v3"
+)
+
+const (
+	textEventStream     = "text/event-stream"
+	lastEventID         = "Last-Event-ID"
+	defaultSSERetry     = 3 * time.Second
+	maxSSELineSize      = 1 << 20
+	defaultSSEEventType = "message"
+)
+
+// Event is a server-sent event.
+type Event struct {
+	ID    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// EventHandler is called for every event received by SSE.
+type EventHandler func(event *Event)
+
+// SSE subscribes to a text/event-stream and calls handler for every event until ctx is done.
+// The request goes through Do, so base url, auth, headers and hooks apply as usual.
+// When the stream ends or fails, it reconnects with Last-Event-ID after the server supplied
+// retry delay, or the backoff strategy, or 3s.
+// A 204 response stops the subscription, a 5xx response is retried, any other non-200 response
+// is returned as an error, as is any error from Do other than a transport error or an open circuit.
+func (c *Cast) SSE(ctx context.Context, request *Request, handler EventHandler) error {
+	request.WithStreamResponse()
+	request.SetHeader("Accept", textEventStream, "Cache-Control", "no-cache")
+
+	var (
+		lastID  string
+		retry   time.Duration
+		delay   time.Duration
+		attempt int
+	)
+	for {
+		if len(lastID) > 0 {
+			request.SetHeader(lastEventID, lastID)
+		}
+
+		resp, err := c.Do(ctx, request)
+		streamed := err == nil
+		if streamed {
+			var received bool
+			received, err = c.readEvents(resp, handler, &lastID, &retry)
+			if received {
+				attempt = 0
+			}
+		}
+		if err == errSSEStop {
+			return nil
+		}
+		if !sseRetryable(err, streamed) {
+			return err
+		}
+
+		attempt++
+		previousDelay := delay
+		delay = defaultSSERetry
+		switch {
+		case retry > 0:
+			delay = retry
+		case c.stg != nil:
+			delay = c.stg.Backoff(&BackoffAttempt{
+				Number:        attempt,
+				PreviousDelay: previousDelay,
+				Response:      resp,
+				Err:           err,
+			})
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+const errSSEStop Error = "cast: sse stopped by server"
+
+type sseStatusError struct {
+	statusCode int
+}
+
+func (err *sseStatusError) Error() string {
+	return fmt.Sprintf("cast: unexpected sse status code %d", err.statusCode)
+}
+
+// sseRetryable reports whether SSE reconnects after err, streamed being true if the error
+// happened while reading the events.
+func sseRetryable(err error, streamed bool) bool {
+	var statusErr *sseStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.statusCode >= http.StatusInternalServerError
+	}
+	if streamed {
+		return true
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	var circuitErr circuit.Error
+	return errors.As(err, &circuitErr) && circuitErr.CircuitOpen()
+}
+
+func (c *Cast) readEvents(resp *Response, handler EventHandler, lastID *string, retry *time.Duration) (bool, error) {
+	defer resp.Close()
+
+	switch {
+	case resp.StatusCode() == http.StatusNoContent:
+		return false, errSSEStop
+	case resp.StatusCode() != http.StatusOK:
+		return false, &sseStatusError{statusCode: resp.StatusCode()}
+	}
+
+	var (
+		received bool
+		event    = new(Event)
+		data     bytes.Buffer
+	)
+	scanner := bufio.NewScanner(resp.Stream())
+	scanner.Buffer(make([]byte, 4096), maxSSELineSize)
+	scanner.Split(scanSSELines)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			if data.Len() > 0 {
+				event.ID = *lastID
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if len(event.Event) == 0 {
+					event.Event = defaultSSEEventType
+				}
+				handler(event)
+				received = true
+			}
+			event = new(Event)
+			data.Reset()
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				*lastID = value
+			}
+		case "retry":
+			ms, err := strconv.ParseInt(value, 10, 64)
+			if err == nil {
+				*retry = time.Duration(ms) * time.Millisecond
+				event.Retry = *retry
+			}
+		}
+	}
+	err := scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	return received, err
+}
+
+// scanSSELines splits lines ended by "\r\n", "\n" or "\r".
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,94 @@
+package cast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCast_SSE(t *testing.T) {
+	var connections int32
+	var lastIDs []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIDs = append(lastIDs, r.Header.Get(lastEventID))
+		if atomic.AddInt32(&connections, 1) > 1 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set(contentType, textEventStream)
+		_, _ = fmt.Fprint(w, ": comment\nretry: 10\n\nevent: add\ndata: a\ndata: b\nid: 1\n\r\ndata:c\r\n\r\n")
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+
+	var events []*Event
+	err = c.SSE(context.TODO(), c.NewRequest().WithPath("/events"), func(event *Event) {
+		events = append(events, event)
+	})
+	ok(t, err)
+
+	assert(t, len(events) == 2, "unexpected events: %d", len(events))
+	assert(t, events[0].Event == "add" && events[0].Data == "a\nb" && events[0].ID == "1", "unexpected event: %+v", events[0])
+	assert(t, events[1].Event == "message" && events[1].Data == "c" && events[1].ID == "1", "unexpected event: %+v", events[1])
+	assert(t, len(lastIDs) == 2 && lastIDs[0] == "" && lastIDs[1] == "1", "unexpected Last-Event-ID: %v", lastIDs)
+}
+
+func TestCast_SSEStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL))
+	ok(t, err)
+	err = c.SSE(context.TODO(), c.NewRequest(), func(*Event) {})
+	assert(t, err != nil, "expected error")
+}
+
+func TestCast_SSETerminalErrors(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithConstantBackoffStrategy(time.Millisecond))
+	ok(t, err)
+	err = c.SSE(context.TODO(), c.NewRequest(), func(*Event) {})
+	var statusErr *sseStatusError
+	assert(t, errors.As(err, &statusErr) && statusErr.statusCode == http.StatusForbidden, "unexpected error %v", err)
+	assert(t, atomic.LoadInt32(&calls) == 2, "expected a single reconnection after a 5xx, got %d calls", calls)
+
+	request := c.NewRequest().WithPath("/{").WithPathParam(map[string]interface{}{"id": 1})
+	err = c.SSE(context.TODO(), request, func(*Event) {})
+	assert(t, err != nil && atomic.LoadInt32(&calls) == 2, "expected a terminal error before sending, got %v", err)
+}
+
+func TestCast_SSEErrorOnStatus(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithErrorOnStatus(), WithConstantBackoffStrategy(time.Millisecond))
+	ok(t, err)
+	err = c.SSE(context.TODO(), c.NewRequest(), func(*Event) {})
+	assert(t, IsForbidden(err), "unexpected error %v", err)
+	assert(t, atomic.LoadInt32(&calls) == 2, "expected a single reconnection after a 5xx, got %d calls", calls)
+}
@@ -0,0 +1,41 @@
+package cast
+
+import "time"
+
+// Timing holds the timings of an attempt.
+// DNS, Connect and TLSHandshake are zero when the connection is reused,
+// Receiving is zero for a streamed response.
+type Timing struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt       int
+	RemoteAddress string
+	ConnReused    bool
+	ConnWasIdle   bool
+	ConnIdleTime  time.Duration
+	Start         time.Time
+	DNS           time.Duration
+	Connect       time.Duration
+	TLSHandshake  time.Duration
+	Sending       time.Duration
+	Waiting       time.Duration
+	Receiving     time.Duration
+	Total         time.Duration
+}
+
+func (prof *profiling) timing(attempt int, remoteAddress string) Timing {
+	return Timing{
+		Attempt:       attempt,
+		RemoteAddress: remoteAddress,
+		ConnReused:    prof.connReused,
+		ConnWasIdle:   prof.connWasIdle,
+		ConnIdleTime:  prof.connIdleTime,
+		Start:         prof.requestStart,
+		DNS:           prof.dnsCost,
+		Connect:       prof.connectCost,
+		TLSHandshake:  prof.tlsHandshakeCost,
+		Sending:       prof.sendingCost,
+		Waiting:       prof.waitingCost,
+		Receiving:     prof.receivingCost,
+		Total:         prof.requestCost,
+	}
+}
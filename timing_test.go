@@ -0,0 +1,37 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestResponse_Timings(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c, err := New(WithBaseURL(ts.URL), WithRetry(1), WithRetryStatusCodes(http.StatusBadGateway), WithConstantBackoffStrategy(0))
+	ok(t, err)
+	resp, err := c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+
+	timings := resp.Timings()
+	assert(t, len(timings) == 2, "unexpected timings %d", len(timings))
+	for i, timing := range timings {
+		assert(t, timing.Attempt == i+1, "%d: unexpected attempt %d", i, timing.Attempt)
+		assert(t, timing.RemoteAddress == ts.Listener.Addr().String(), "%d: unexpected remote address %s", i, timing.RemoteAddress)
+		assert(t, timing.Total > 0 && !timing.Start.IsZero(), "%d: unexpected total", i)
+	}
+	assert(t, !timings[0].ConnReused && timings[0].Connect > 0, "expected a new connection")
+	assert(t, timings[1].ConnReused, "expected the connection to be reused")
+	assert(t, timings[1].Start.After(timings[0].Start), "expected the second attempt to start later")
+}
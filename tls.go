@@ -0,0 +1,94 @@
+package cast
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsClientConfig returns the tls config of cast, creating it if necessary.
+func (c *Cast) tlsClientConfig() *tls.Config {
+	if c.tlsConfig == nil {
+		c.tlsConfig = &tls.Config{}
+	}
+	return c.tlsConfig
+}
+
+func loadCertPool(files ...string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	for _, file := range files {
+		pem, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("no certificate found in " + file)
+		}
+	}
+	return pool, nil
+}
+
+// certificateReloader serves a client certificate and reloads it
+// from disk when either the cert or the key file changes.
+type certificateReloader struct {
+	certFile    string
+	keyFile     string
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certificateReloader) modTimes() (certModTime, keyModTime time.Time, err error) {
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+func (r *certificateReloader) reload() error {
+	certModTime, keyModTime, err := r.modTimes()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.certModTime = certModTime
+	r.keyModTime = keyModTime
+	return nil
+}
+
+// GetClientCertificate implements tls.Config.GetClientCertificate.
+// The previous certificate keeps being served if a rotation is only half written.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	certModTime, keyModTime, err := r.modTimes()
+	if err == nil && (!certModTime.Equal(r.certModTime) || !keyModTime.Equal(r.keyModTime)) {
+		_ = r.reload()
+	}
+	return r.cert, nil
+}
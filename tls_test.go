@@ -0,0 +1,97 @@
+package cast
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeKeyPair(t *testing.T, dir, cn string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ok(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, &key.PublicKey, key)
+	ok(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	ok(t, err)
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	ok(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	ok(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cast")
+	ok(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := writeKeyPair(t, dir, "first")
+	r, err := newCertificateReloader(certFile, keyFile)
+	ok(t, err)
+	first, err := r.GetClientCertificate(nil)
+	ok(t, err)
+
+	writeKeyPair(t, dir, "second")
+	later := time.Now().Add(time.Minute)
+	ok(t, os.Chtimes(certFile, later, later))
+	ok(t, os.Chtimes(keyFile, later, later))
+
+	second, err := r.GetClientCertificate(nil)
+	ok(t, err)
+	leaf, err := x509.ParseCertificate(second.Certificate[0])
+	ok(t, err)
+	assert(t, first != second, "expected certificate to be reloaded")
+	assert(t, leaf.Subject.CommonName == "second", "unexpected common name: %s", leaf.Subject.CommonName)
+}
+
+func TestWithTLSOptions(t *testing.T) {
+	c, err := New(WithMinTLSVersion(tls.VersionTLS12), WithServerName("internal"))
+	ok(t, err)
+	transport := c.client.Transport.(*http.Transport)
+	assert(t, transport.TLSClientConfig.MinVersion == tls.VersionTLS12, "unexpected MinVersion")
+	assert(t, transport.TLSClientConfig.ServerName == "internal", "unexpected ServerName")
+
+	ok(t, c.SetInsecureSkipVerify(true))
+	assert(t, transport.TLSClientConfig.InsecureSkipVerify, "expected InsecureSkipVerify")
+	ok(t, c.SetInsecureSkipVerify(false))
+	assert(t, !transport.TLSClientConfig.InsecureSkipVerify, "unexpected InsecureSkipVerify")
+}
+
+func TestWithTLSOptions_NotApplicable(t *testing.T) {
+	_, err := New(WithHTTPClient(&http.Client{}), WithMinTLSVersion(tls.VersionTLS12))
+	assert(t, err != nil, "expected an error for tls options with a supplied client")
+
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("unreachable")
+	})
+	_, err = New(WithRoundTripper(rt), WithServerName("internal"))
+	assert(t, err != nil, "expected an error for tls options with a custom round tripper")
+
+	_, err = New(WithRoundTripper(rt))
+	ok(t, err)
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
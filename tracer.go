@@ -0,0 +1,130 @@
+package cast
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+)
+
+// Tracer starts the spans of cast, a span for every Do and a child span for every attempt.
+type Tracer interface {
+	// Start starts a client span as a child of the span in ctx, if any.
+	Start(ctx context.Context, name string) (context.Context, Span)
+	// Inject propagates the span in ctx into the request header.
+	Inject(ctx context.Context, header http.Header) error
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	AddEvent(name string, at time.Time)
+	SetError(err error)
+	End()
+}
+
+type opentracingTracer struct {
+	tracer opentracing.Tracer
+}
+
+// NewOpentracingTracer adapts an opentracing tracer, opentracing.GlobalTracer() if nil.
+// Spans are only started when ctx already carries a span.
+func NewOpentracingTracer(tracer opentracing.Tracer) Tracer {
+	return &opentracingTracer{tracer: tracer}
+}
+
+func (t *opentracingTracer) get() opentracing.Tracer {
+	if t.tracer == nil {
+		return opentracing.GlobalTracer()
+	}
+	return t.tracer
+}
+
+func (t *opentracingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	parent := opentracing.SpanFromContext(ctx)
+	if parent == nil {
+		return ctx, noopSpan{}
+	}
+	span := t.get().StartSpan(name, opentracing.ChildOf(parent.Context()), ext.SpanKindRPCClient)
+	return opentracing.ContextWithSpan(ctx, span), &opentracingSpan{span: span}
+}
+
+func (t *opentracingTracer) Inject(ctx context.Context, header http.Header) error {
+	span := opentracing.SpanFromContext(ctx)
+	if span == nil {
+		return nil
+	}
+	return t.get().Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier(header))
+}
+
+type opentracingSpan struct {
+	span opentracing.Span
+}
+
+func (s *opentracingSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetTag(key, value)
+}
+
+func (s *opentracingSpan) AddEvent(name string, at time.Time) {
+	s.span.LogFields(otlog.String("event", name), otlog.String("time", at.Format(time.RFC3339Nano)))
+}
+
+func (s *opentracingSpan) SetError(err error) {
+	ext.Error.Set(s.span, true)
+	s.span.LogFields(otlog.Error(err))
+}
+
+func (s *opentracingSpan) End() {
+	s.span.Finish()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+
+func (noopSpan) AddEvent(string, time.Time) {}
+
+func (noopSpan) SetError(error) {}
+
+func (noopSpan) End() {}
+
+// traceTimings adds the httptrace timings of the attempt as span events.
+func traceTimings(span Span, prof *profiling) {
+	events := []struct {
+		name string
+		at   time.Time
+	}{
+		{"dns.start", prof.dnsStart},
+		{"dns.done", prof.dnsDone},
+		{"connect.start", prof.connectStart},
+		{"connect.done", prof.connectDone},
+		{"tls.start", prof.tlsHandshakeStart},
+		{"tls.done", prof.tlsHandshakeDone},
+		{"request.wrote_headers", prof.sendingStart},
+		{"request.wrote", prof.sendingDone},
+		{"response.first_byte", prof.waitingDone},
+	}
+	for _, e := range events {
+		if !e.at.IsZero() {
+			span.AddEvent(e.name, e.at)
+		}
+	}
+}
+
+// endSpan records the outcome of a request or an attempt, status codes from 400 are errors.
+func (c *Cast) endSpan(span Span, resp *Response, err error) {
+	switch {
+	case err != nil:
+		span.SetError(c.redactor.err(err))
+	case resp != nil && resp.statusCode > 0:
+		span.SetAttribute("http.status_code", resp.statusCode)
+		if resp.statusCode >= http.StatusBadRequest {
+			span.SetError(fmt.Errorf("http status code %d", resp.statusCode))
+		}
+	}
+	span.End()
+}
@@ -0,0 +1,36 @@
+package cast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/mocktracer"
+)
+
+func TestOpentracingTracer(t *testing.T) {
+	var spanID string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanID = r.Header.Get("Mockpfx-Ids-Spanid")
+	}))
+	defer ts.Close()
+
+	tracer := mocktracer.New()
+	c, err := New(WithBaseURL(ts.URL), WithTracer(NewOpentracingTracer(tracer)))
+	ok(t, err)
+
+	_, err = c.Do(context.TODO(), c.NewRequest())
+	ok(t, err)
+	assert(t, len(tracer.FinishedSpans()) == 0, "expected no span without a parent")
+
+	parent := tracer.StartSpan("parent")
+	_, err = c.Do(opentracing.ContextWithSpan(context.TODO(), parent), c.NewRequest())
+	ok(t, err)
+	spans := tracer.FinishedSpans()
+	assert(t, len(spans) == 2, "unexpected spans %d", len(spans))
+	assert(t, spans[0].ParentID == spans[1].SpanContext.SpanID, "expected the attempt span to be a child")
+	assert(t, spans[1].Tag("http.status_code") == http.StatusOK, "unexpected status code tag")
+	assert(t, len(spanID) > 0, "expected the span to be injected")
+}
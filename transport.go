@@ -0,0 +1,140 @@
+package cast
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultDialTimeout           = 30 * time.Second
+	defaultKeepAlive             = 30 * time.Second
+	defaultMaxIdleConns          = 2000
+	defaultMaxIdleConnsPerHost   = 2000
+	defaultIdleConnTimeout       = 90 * time.Second
+	defaultTLSHandshakeTimeout   = 10 * time.Second
+	defaultExpectContinueTimeout = 1 * time.Second
+)
+
+// transportConfig holds the knobs of the default transport.
+type transportConfig struct {
+	dialTimeout           time.Duration
+	keepAlive             time.Duration
+	maxIdleConns          int
+	maxIdleConnsPerHost   int
+	maxConnsPerHost       int
+	idleConnTimeout       time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+	expectContinueTimeout time.Duration
+}
+
+func defaultTransportConfig() transportConfig {
+	return transportConfig{
+		dialTimeout:           defaultDialTimeout,
+		keepAlive:             defaultKeepAlive,
+		maxIdleConns:          defaultMaxIdleConns,
+		maxIdleConnsPerHost:   defaultMaxIdleConnsPerHost,
+		idleConnTimeout:       defaultIdleConnTimeout,
+		tlsHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		expectContinueTimeout: defaultExpectContinueTimeout,
+	}
+}
+
+func (tc transportConfig) newTransport() *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   tc.dialTimeout,
+			KeepAlive: tc.keepAlive,
+		}).DialContext,
+		MaxIdleConns:          tc.maxIdleConns,
+		MaxIdleConnsPerHost:   tc.maxIdleConnsPerHost,
+		MaxConnsPerHost:       tc.maxConnsPerHost,
+		IdleConnTimeout:       tc.idleConnTimeout,
+		TLSHandshakeTimeout:   tc.tlsHandshakeTimeout,
+		ResponseHeaderTimeout: tc.responseHeaderTimeout,
+		ExpectContinueTimeout: tc.expectContinueTimeout,
+	}
+}
+
+// buildClient returns the http client used by cast.
+// A client supplied by WithHTTPClient wins, then a round tripper supplied by
+// WithTransport or WithRoundTripper, then the default transport built from the knobs.
+// The tls config, if any, is applied to the default transport and to a clone of a supplied
+// *http.Transport, never to the supplied transport itself. It is an error to combine it
+// with a supplied client or with a round tripper which is not an *http.Transport.
+// The recorder, if any, wraps whatever transport is picked.
+func (c *Cast) buildClient() (*http.Client, error) {
+	if c.client != nil {
+		if c.tlsConfig != nil {
+			return nil, errors.New("tls options cannot be applied to a client supplied by WithHTTPClient")
+		}
+		if c.recorder == nil {
+			return c.client, nil
+		}
+		client := *c.client
+		client.Transport = c.record(client.Transport)
+		return &client, nil
+	}
+	rt := c.roundTripper
+	switch t, ok := rt.(*http.Transport); {
+	case rt == nil:
+		c.transport = c.transportConfig.newTransport()
+		rt = c.transport
+	case ok && c.tlsConfig != nil:
+		c.transport = t.Clone()
+		rt = c.transport
+	case c.tlsConfig != nil:
+		return nil, errors.New("tls options cannot be applied to a round tripper which is not an *http.Transport")
+	}
+	if c.transport != nil && c.tlsConfig != nil {
+		c.transport.TLSClientConfig = c.tlsConfig
+	}
+	if c.recorder != nil {
+		rt = c.record(rt)
+	}
+	return &http.Client{
+		Transport: rt,
+		Timeout:   c.httpClientTimeout,
+	}, nil
+}
+
+func (c *Cast) record(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	c.recorder.next = next
+	c.recorder.redactor = c.redactor
+	return c.recorder
+}
+
+// ownTransport returns the *http.Transport used by the client, behind the recorder if any,
+// cloning it first if it was supplied by the caller, so that it can be changed safely.
+func (c *Cast) ownTransport() (*http.Transport, error) {
+	if c.transport != nil {
+		return c.transport, nil
+	}
+	rt := c.client.Transport
+	rec, recorded := rt.(*recorder)
+	if recorded {
+		rt = rec.next
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, errors.New("http client type assertion failed")
+	}
+	c.transport = t.Clone()
+	if recorded {
+		rec.next = c.transport
+		return c.transport, nil
+	}
+	client := *c.client
+	client.Transport = c.transport
+	c.client = &client
+	return c.transport, nil
+}
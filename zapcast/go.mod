@@ -0,0 +1,26 @@
+module github.com/xiaojiaoyu100/cast/zapcast
+
+go 1.22
+
+require (
+	github.com/xiaojiaoyu100/cast v0.0.0
+	go.uber.org/zap v1.27.0
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/cep21/circuit/v3 v3.1.0 // indirect
+	github.com/google/go-querystring v1.0.0 // indirect
+	github.com/jtacoma/uritemplates v1.0.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
+	github.com/opentracing/opentracing-go v1.2.0 // indirect
+	github.com/sirupsen/logrus v1.4.2 // indirect
+	go.uber.org/multierr v1.10.0 // indirect
+	golang.org/x/sys v0.0.0-20191029155521-f43be2a4598c // indirect
+	golang.org/x/time v0.3.0 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/xiaojiaoyu100/cast => ../
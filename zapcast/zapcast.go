@@ -0,0 +1,52 @@
+// Package zapcast adapts a zap logger to cast.
+package zapcast
+
+import (
+	"github.com/xiaojiaoyu100/cast"
+	"go.uber.org/zap"
+)
+
+type logger struct {
+	logger *zap.Logger
+}
+
+// NewLogger returns a cast.Logger writing to l.
+func NewLogger(l *zap.Logger) cast.Logger {
+	return &logger{logger: l.WithOptions(zap.AddCallerSkip(1))}
+}
+
+func zapFields(fields []cast.Field) []zap.Field {
+	zf := make([]zap.Field, 0, len(fields))
+	for _, f := range fields {
+		if err, ok := f.Value.(error); ok {
+			zf = append(zf, zap.NamedError(f.Key, err))
+			continue
+		}
+		zf = append(zf, zap.Any(f.Key, f.Value))
+	}
+	return zf
+}
+
+func (l *logger) Debug(msg string, fields ...cast.Field) {
+	if ce := l.logger.Check(zap.DebugLevel, msg); ce != nil {
+		ce.Write(zapFields(fields)...)
+	}
+}
+
+func (l *logger) Info(msg string, fields ...cast.Field) {
+	if ce := l.logger.Check(zap.InfoLevel, msg); ce != nil {
+		ce.Write(zapFields(fields)...)
+	}
+}
+
+func (l *logger) Warn(msg string, fields ...cast.Field) {
+	if ce := l.logger.Check(zap.WarnLevel, msg); ce != nil {
+		ce.Write(zapFields(fields)...)
+	}
+}
+
+func (l *logger) Error(msg string, fields ...cast.Field) {
+	if ce := l.logger.Check(zap.ErrorLevel, msg); ce != nil {
+		ce.Write(zapFields(fields)...)
+	}
+}
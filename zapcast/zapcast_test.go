@@ -0,0 +1,37 @@
+package zapcast
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xiaojiaoyu100/cast"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestLogger(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ts.Close()
+
+	core, logs := observer.New(zap.InfoLevel)
+	c, err := cast.New(cast.WithBaseURL(ts.URL), cast.WithLogger(NewLogger(zap.New(core))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Do(context.TODO(), c.NewRequest().WithPath("/path")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected entries %d", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["method"] != "GET" || fields["url"] != ts.URL+"/path" || fields["status"] != int64(http.StatusAccepted) {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}